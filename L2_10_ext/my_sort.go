@@ -1,29 +1,146 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"main/parsingFlags"
 	readinput "main/readInput"
 	"main/sorting"
+	writeoutput "main/writeOutput"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 	config, files, err := parsingflags.ParseFlags()
+	if errors.Is(err, parsingflags.ErrHelp) {
+		parsingflags.Usage()
+		return
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	lines, err := readinput.ReadInput(files)
+	if config.Debug {
+		for _, warning := range config.Warnings() {
+			fmt.Fprintf(os.Stderr, "Debug: %s\n", warning)
+		}
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	sorter := sorting.New(sorting.WithConfig(config))
+
+	if config.CheckSorted {
+		os.Exit(checkSorted(ctx, sorter, files, config))
+	}
+
+	output, err := writeoutput.Open(config.Output)
 	if err != nil {
-		if err != readinput.ErrLargeFile {
-			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-			os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error opening output: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(ctx, sorter, files, config, output); err != nil {
+		output.Abort()
+		if code, ok := signalExitCode(ctx); ok {
+			os.Exit(code)
 		}
-		external.Sorting(config)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := output.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run выбирает способ сортировки и пишет результат в output
+func run(ctx context.Context, sorter *sorting.Sorter, files []string, config *parsingflags.Config, output *writeoutput.Output) error {
+	if config.Merge {
+		return sorter.Merge(ctx, files, output)
 	}
+	return sorter.SortFiles(ctx, files, output)
+}
 
-	sorting.InternalSort(lines, config)
+// signalError — причина отмены контекста по сигналу
+type signalError struct {
+	sig os.Signal
+}
 
+func (e *signalError) Error() string {
+	return fmt.Sprintf("interrupted by %v", e.sig)
+}
+
+// signalContext отменяет контекст при SIGINT или SIGTERM: сортировка останавливается
+// и удаляет временные файлы. Повторный сигнал завершает процесс сразу
+func signalContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			cancel(&signalError{sig: sig})
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(nil)
+	}
+}
+
+// signalExitCode возвращает код выхода, если контекст отменен сигналом.
+// Как принято в shell, код выхода — 128 плюс номер сигнала
+func signalExitCode(ctx context.Context) (int, bool) {
+	var sigErr *signalError
+	if !errors.As(context.Cause(ctx), &sigErr) {
+		return 0, false
+	}
+	if s, ok := sigErr.sig.(syscall.Signal); ok {
+		return 128 + int(s), true
+	}
+	return 1, true
+}
+
+// checkSorted проверяет порядок входа для -c и -C и возвращает код выхода:
+// 0 — вход отсортирован, 1 — найдено нарушение порядка, 2 — ошибка, как в GNU sort
+func checkSorted(ctx context.Context, sorter *sorting.Sorter, files []string, config *parsingflags.Config) int {
+	name := readinput.Stdin
+	if len(files) > 0 {
+		name = files[0]
+	}
+	input, err := readinput.Open(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		return 2
+	}
+	defer input.Close()
+
+	err = sorter.Check(ctx, input)
+	if err == nil {
+		return 0
+	}
+	if code, ok := signalExitCode(ctx); ok {
+		return code
+	}
+
+	var disorder *sorting.DisorderError
+	if !errors.As(err, &disorder) {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		return 2
+	}
+	if !config.CheckQuiet {
+		disorder.File = name
+		fmt.Fprintln(os.Stderr, disorder)
+	}
+	return 1
 }
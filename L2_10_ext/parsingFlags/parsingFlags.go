@@ -1,146 +1,756 @@
 package parsingflags
 
 import (
-	"flag"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-// Config описывает структуру флагов
-type Config struct {
-	KeyColumn    int
+// Ordering описывает модификаторы сравнения, общие для ключа и всей строки
+type Ordering struct {
 	Numeric      bool
 	Reverse      bool
-	Unique       bool
 	Month        bool
-	IgnoreBlanks bool
-	CheckSorted  bool
 	HumanNumeric bool
-	Delimiter    string
+	IgnoreBlanks bool
+
+	FoldCase          bool
+	Dictionary        bool
+	IgnoreNonPrinting bool
+
+	GeneralNumeric bool
+	Version        bool
+	Random         bool
+
+	// Collate включает сравнение по правилам Unicode; задается только глобально (--collate)
+	Collate bool
+}
+
+// KeySpec описывает ключ сортировки -k POS1[,POS2], поля и символы нумеруются с 1
+type KeySpec struct {
+	StartField int
+	StartChar  int
+	EndField   int // 0 — ключ продолжается до конца строки
+	EndChar    int // 0 — ключ заканчивается в конце поля EndField
+	Ordering
+}
+
+// Config описывает структуру флагов
+type Config struct {
+	Ordering
+	Keys        []KeySpec
+	Unique      bool
+	Stable      bool
+	CheckSorted bool
+	CheckQuiet  bool // -C: проверять порядок без диагностики
+	Merge       bool
+	ZeroTerm    bool   // записи разделяются нулевым байтом, а не переводом строки
+	Delimiter   string // пустой разделитель — поля разделяются последовательностями пробелов
+	Parallel    int    // число горутин для сортировки в памяти
+	Output      string // файл вывода, пустая строка — stdout
+	RandomSeed  uint64 // соль хеша для -R; одинаковая соль дает одинаковый порядок
+	BufferSize  int64  // бюджет памяти -S в байтах, 0 — значение по умолчанию
+	TempDirs    []string
+	Compress    string // встроенный метод сжатия временных файлов: gzip или flate
+	BatchSize   int    // число файлов, сливаемых за один проход; 0 — значение по умолчанию
+	CSV         bool   // записи и поля разбираются как CSV, -t задает разделитель полей
+	Header      int    // число первых записей, которые выводятся первыми без сортировки
+	Repeated    bool   // --repeated: выводить только группы записей с равными ключами, как uniq -d
+	Count       bool   // --count: выводить запись группы с числом записей в ней, как uniq -c
+	Top         int    // --top: вывести только первые Top записей в порядке сортировки
+	Bottom      int    // --bottom: вывести только последние Bottom записей в порядке сортировки
+	Debug       bool   // --debug: подчеркивать ключи под каждой строкой и предупреждать о неоднозначных опциях
 }
 
+// ErrHelp возвращается ParseFlags, когда запрошена справка --help
+var ErrHelp = errors.New("help requested")
+
 // ParseFlags создает конфиг по объявленным флагам
 func ParseFlags() (*Config, []string, error) {
 	config := &Config{
-		Delimiter: "\t",
+		Parallel: runtime.GOMAXPROCS(0),
 	}
 
-	var keyColumn int
-
-	fs := flag.NewFlagSet("sort", flag.ContinueOnError)
-	fs.Usage = Usage
-
-	fs.IntVar(&keyColumn, "k", 0, "sort by key column number")
-	fs.BoolVar(&config.Numeric, "n", false, "sort numerically")
-	fs.BoolVar(&config.Reverse, "r", false, "reverse sort order")
-	fs.BoolVar(&config.Unique, "u", false, "output only unique lines")
-	fs.BoolVar(&config.Month, "M", false, "sort by month names")
-	fs.BoolVar(&config.IgnoreBlanks, "b", false, "ignore trailing blanks")
-	fs.BoolVar(&config.CheckSorted, "c", false, "check if input is sorted")
-	fs.BoolVar(&config.HumanNumeric, "h", false, "sort by human-readable numbers")
-
 	args := os.Args[1:]
 	var nonFlagArgs []string
-	var skipNext bool
+	var hasSeed bool
 
-	for i := 0; i < len(args); i++ {
-		if skipNext {
-			skipNext = false
-			continue
+	// optionArg возвращает значение опции: слитное (-k2,2n) или следующий аргумент (-k 2,2n)
+	var i int
+	optionArg := func(f byte, rest string) (string, error) {
+		if rest != "" {
+			return rest, nil
 		}
+		if i+1 >= len(args) {
+			return "", fmt.Errorf("option -%c requires an argument", f)
+		}
+		i++
+		return args[i], nil
+	}
 
+	for i = 0; i < len(args); i++ {
 		arg := args[i]
 
 		if strings.HasPrefix(arg, "-") && len(arg) > 1 && !strings.HasPrefix(arg, "--") {
-			if len(arg) > 2 {
-				flags := arg[1:]
-				for _, f := range flags {
-					switch f {
-					case 'n':
-						config.Numeric = true
-					case 'r':
-						config.Reverse = true
-					case 'u':
-						config.Unique = true
-					case 'M':
-						config.Month = true
-					case 'b':
-						config.IgnoreBlanks = true
-					case 'c':
-						config.CheckSorted = true
-					case 'h':
-						config.HumanNumeric = true
-					case 'k':
-						if i+1 < len(args) {
-							val := args[i+1]
-							if n, err := strconv.Atoi(val); err == nil && n >= 0 {
-								keyColumn = n
-								skipNext = true
-							} else {
-								return nil, nil, fmt.Errorf("invalid column number: %s", val)
-							}
-						} else {
-							return nil, nil, fmt.Errorf("option -k requires an argument")
-						}
-					default:
-						return nil, nil, fmt.Errorf("unknown option: -%c", f)
+			flags := arg[1:]
+			for j := 0; j < len(flags); j++ {
+				switch f := flags[j]; f {
+				case 'n':
+					config.Numeric = true
+				case 'r':
+					config.Reverse = true
+				case 'u':
+					config.Unique = true
+				case 'M':
+					config.Month = true
+				case 'b':
+					config.IgnoreBlanks = true
+				case 'c':
+					config.CheckSorted = true
+				case 'C':
+					config.CheckSorted = true
+					config.CheckQuiet = true
+				case 's':
+					config.Stable = true
+				case 'm':
+					config.Merge = true
+				case 'z':
+					config.ZeroTerm = true
+				case 'h':
+					config.HumanNumeric = true
+				case 'f':
+					config.FoldCase = true
+				case 'd':
+					config.Dictionary = true
+				case 'i':
+					config.IgnoreNonPrinting = true
+				case 'g':
+					config.GeneralNumeric = true
+				case 'V':
+					config.Version = true
+				case 'R':
+					config.Random = true
+				case 'k':
+					val, err := optionArg(f, flags[j+1:])
+					if err != nil {
+						return nil, nil, err
 					}
-				}
-			} else {
-				switch arg {
-				case "-k":
-					if i+1 < len(args) {
-						val := args[i+1]
-						if n, err := strconv.Atoi(val); err == nil && n >= 0 {
-							keyColumn = n
-							skipNext = true
-						} else {
-							return nil, nil, fmt.Errorf("invalid column number: %s", val)
-						}
-					} else {
-						return nil, nil, fmt.Errorf("option -k requires an argument")
+					key, err := parseKeySpec(val)
+					if err != nil {
+						return nil, nil, err
 					}
-				default:
-					if err := fs.Parse([]string{arg}); err != nil {
+					config.Keys = append(config.Keys, key)
+					j = len(flags)
+				case 'o':
+					val, err := optionArg(f, flags[j+1:])
+					if err != nil {
+						return nil, nil, err
+					}
+					config.Output = val
+					j = len(flags)
+				case 'S':
+					val, err := optionArg(f, flags[j+1:])
+					if err != nil {
+						return nil, nil, err
+					}
+					if config.BufferSize, err = parseBufferSize(val); err != nil {
+						return nil, nil, err
+					}
+					j = len(flags)
+				case 'T':
+					val, err := optionArg(f, flags[j+1:])
+					if err != nil {
+						return nil, nil, err
+					}
+					config.TempDirs = append(config.TempDirs, val)
+					j = len(flags)
+				case 't':
+					val, err := optionArg(f, flags[j+1:])
+					if err != nil {
+						return nil, nil, err
+					}
+					if config.Delimiter, err = parseSeparator(val); err != nil {
 						return nil, nil, err
 					}
+					j = len(flags)
+				default:
+					return nil, nil, fmt.Errorf("unknown option: -%c", f)
+				}
+			}
+		} else if arg == "--" {
+			nonFlagArgs = append(nonFlagArgs, args[i+1:]...)
+			break
+		} else if strings.HasPrefix(arg, "--") {
+			// Значение длинной опции задается через = (--parallel=4) или следующим аргументом
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			longArg := func() (string, error) {
+				if hasValue {
+					return value, nil
+				}
+				if i+1 >= len(args) {
+					return "", fmt.Errorf("option --%s requires an argument", name)
+				}
+				i++
+				return args[i], nil
+			}
+
+			switch name {
+			case "help":
+				return nil, nil, ErrHelp
+			case "collate":
+				if hasValue {
+					return nil, nil, fmt.Errorf("option --%s doesn't allow an argument", name)
+				}
+				config.Collate = true
+			case "debug":
+				if hasValue {
+					return nil, nil, fmt.Errorf("option --%s doesn't allow an argument", name)
+				}
+				config.Debug = true
+			case "repeated", "count":
+				if hasValue {
+					return nil, nil, fmt.Errorf("option --%s doesn't allow an argument", name)
+				}
+				if name == "repeated" {
+					config.Repeated = true
+				} else {
+					config.Count = true
+				}
+			case "csv":
+				if hasValue {
+					return nil, nil, fmt.Errorf("option --%s doesn't allow an argument", name)
 				}
+				config.CSV = true
+			case "header":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				n, err := strconv.Atoi(val)
+				if err != nil || n < 0 {
+					return nil, nil, fmt.Errorf("invalid number of header lines: %s", val)
+				}
+				config.Header = n
+			case "top", "head", "bottom", "tail":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				n, err := strconv.Atoi(val)
+				if err != nil || n < 1 {
+					return nil, nil, fmt.Errorf("invalid number of records for --%s: %s", name, val)
+				}
+				if name == "top" || name == "head" {
+					config.Top = n
+				} else {
+					config.Bottom = n
+				}
+			case "parallel":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				n, err := strconv.Atoi(val)
+				if err != nil || n < 1 {
+					return nil, nil, fmt.Errorf("invalid number of threads: %s", val)
+				}
+				config.Parallel = n
+			case "batch-size":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				n, err := strconv.Atoi(val)
+				if err != nil || n < 2 {
+					return nil, nil, fmt.Errorf("invalid --batch-size argument %q: minimum is 2", val)
+				}
+				config.BatchSize = n
+			case "random-source":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				if config.RandomSeed, err = readRandomSource(val); err != nil {
+					return nil, nil, err
+				}
+				hasSeed = true
+			case "compress-program":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				if val != "gzip" && val != "flate" {
+					return nil, nil, fmt.Errorf("unsupported compress program %q: only built-in gzip and flate are available", val)
+				}
+				config.Compress = val
+			case "seed":
+				val, err := longArg()
+				if err != nil {
+					return nil, nil, err
+				}
+				if config.RandomSeed, err = strconv.ParseUint(val, 10, 64); err != nil {
+					return nil, nil, fmt.Errorf("invalid seed: %s", val)
+				}
+				hasSeed = true
+			default:
+				return nil, nil, fmt.Errorf("unknown option: --%s", name)
 			}
 		} else {
 			nonFlagArgs = append(nonFlagArgs, arg)
 		}
 	}
 
-	config.KeyColumn = keyColumn
-
-	if config.Numeric && config.Month {
-		return nil, nil, fmt.Errorf("conflicting options: -n and -M")
+	if err := config.Ordering.validate(); err != nil {
+		return nil, nil, err
+	}
+	if config.CheckSorted && config.Merge {
+		return nil, nil, fmt.Errorf("conflicting options: -c and -m")
+	}
+	if config.CheckSorted && config.Output != "" {
+		return nil, nil, fmt.Errorf("conflicting options: -c and -o")
+	}
+	if config.CheckSorted && (config.Repeated || config.Count) {
+		return nil, nil, fmt.Errorf("conflicting options: -c and --repeated/--count")
+	}
+	if (config.Top > 0 || config.Bottom > 0) && (config.Repeated || config.Count) {
+		return nil, nil, fmt.Errorf("conflicting options: --top/--bottom and --repeated/--count")
+	}
+	if config.Top > 0 && config.Bottom > 0 {
+		return nil, nil, fmt.Errorf("conflicting options: --top and --bottom")
+	}
+	if (config.Top > 0 || config.Bottom > 0) && config.Merge {
+		return nil, nil, fmt.Errorf("conflicting options: --top/--bottom and -m")
 	}
-	if config.Numeric && config.HumanNumeric {
-		return nil, nil, fmt.Errorf("conflicting options: -n and -h")
+	if config.CSV && config.ZeroTerm {
+		return nil, nil, fmt.Errorf("conflicting options: --csv and -z")
 	}
-	if config.Month && config.HumanNumeric {
-		return nil, nil, fmt.Errorf("conflicting options: -M and -h")
+	if config.Header > 0 && config.Merge {
+		return nil, nil, fmt.Errorf("conflicting options: --header and -m")
+	}
+	if config.CheckSorted && len(nonFlagArgs) > 1 {
+		return nil, nil, fmt.Errorf("extra operand %q not allowed with -c", nonFlagArgs[1])
+	}
+
+	// Как в GNU sort, ключ без собственных модификаторов наследует глобальные
+	random := config.Random
+	for i := range config.Keys {
+		if config.Keys[i].Ordering == (Ordering{}) {
+			config.Keys[i].Ordering = config.Ordering
+		}
+		config.Keys[i].Collate = config.Collate
+		if err := config.Keys[i].Ordering.validate(); err != nil {
+			return nil, nil, err
+		}
+		random = random || config.Keys[i].Random
+	}
+
+	if random && !hasSeed {
+		config.RandomSeed = rand.Uint64()
 	}
 
 	return config, nonFlagArgs, nil
 }
 
+// Terminator возвращает байт, разделяющий записи на входе и выходе
+func (c *Config) Terminator() byte {
+	if c.ZeroTerm {
+		return 0
+	}
+	return '\n'
+}
+
+// Grouped сообщает, что записи с равными ключами объединяются (-u, --repeated, --count).
+// Тогда, как в GNU sort -u, равенство определяют только ключи, без сравнения строк целиком
+func (c *Config) Grouped() bool {
+	return c.Unique || c.Repeated || c.Count
+}
+
+// CSVComma возвращает разделитель полей CSV для --csv: символ -t или запятую.
+// Без --csv возвращает 0, и записи читаются как обычные строки
+func (c *Config) CSVComma() rune {
+	if !c.CSV {
+		return 0
+	}
+	if c.Delimiter != "" {
+		r, _ := utf8.DecodeRuneInString(c.Delimiter)
+		return r
+	}
+	return ','
+}
+
+// Warnings возвращает замечания --debug о способе сравнения и опциях, которые,
+// скорее всего, работают не так, как ожидалось. Проверки повторяют GNU sort
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.Collate {
+		warnings = append(warnings, "text ordering performed using Unicode collation rules")
+	} else {
+		warnings = append(warnings, "text ordering performed using simple byte comparison")
+	}
+	if c.CSV {
+		warnings = append(warnings, "with --csv, key marks refer to field values with quotes removed")
+	}
+
+	// unused — глобальные модификаторы, которые не унаследовал ни один ключ
+	unused := c.Ordering
+	numeric := len(c.Keys) == 0 && (c.Numeric || c.GeneralNumeric || c.HumanNumeric)
+	for i, key := range c.Keys {
+		keyNumeric := key.Numeric || key.GeneralNumeric || key.HumanNumeric
+		numeric = numeric || keyNumeric
+
+		zeroWidth := key.EndField > 0 && (key.EndField < key.StartField ||
+			(key.EndField == key.StartField && key.EndChar > 0 && key.EndChar < key.StartChar))
+		if zeroWidth {
+			warnings = append(warnings, fmt.Sprintf("key %d has zero width and will be ignored", i+1))
+		}
+
+		// Числа и месяцы сами пропускают ведущие пробелы, но не при смещении .C
+		implicitSkip := keyNumeric || key.Month
+		if !zeroWidth && c.Delimiter == "" && !key.IgnoreBlanks && (!implicitSkip || key.StartChar > 1 || key.EndChar > 0) {
+			warnings = append(warnings, fmt.Sprintf("leading blanks are significant in key %d; consider also specifying 'b'", i+1))
+		}
+
+		if keyNumeric && (key.EndField == 0 || key.StartField < key.EndField) {
+			warnings = append(warnings, fmt.Sprintf("key %d is numeric and spans multiple fields", i+1))
+		}
+
+		unused.IgnoreBlanks = unused.IgnoreBlanks && !key.IgnoreBlanks
+		unused.Dictionary = unused.Dictionary && !key.Dictionary
+		unused.FoldCase = unused.FoldCase && !key.FoldCase
+		unused.GeneralNumeric = unused.GeneralNumeric && !key.GeneralNumeric
+		unused.HumanNumeric = unused.HumanNumeric && !key.HumanNumeric
+		unused.IgnoreNonPrinting = unused.IgnoreNonPrinting && !key.IgnoreNonPrinting
+		unused.Month = unused.Month && !key.Month
+		unused.Numeric = unused.Numeric && !key.Numeric
+		unused.Random = unused.Random && !key.Random
+		unused.Reverse = unused.Reverse && !key.Reverse
+		unused.Version = unused.Version && !key.Version
+	}
+
+	if len(c.Keys) > 0 {
+		// -r без ключей, унаследовавших его, обращает только сравнение строк целиком,
+		// а с -s или -u такого сравнения нет совсем
+		lastResort := !c.Stable && !c.Grouped()
+		var opts strings.Builder
+		for _, opt := range []struct {
+			set  bool
+			name byte
+		}{
+			{unused.IgnoreBlanks, 'b'}, {unused.Dictionary, 'd'}, {unused.FoldCase, 'f'},
+			{unused.GeneralNumeric, 'g'}, {unused.HumanNumeric, 'h'}, {unused.IgnoreNonPrinting, 'i'},
+			{unused.Month, 'M'}, {unused.Numeric, 'n'}, {unused.Random, 'R'},
+			{unused.Reverse && !lastResort, 'r'}, {unused.Version, 'V'},
+		} {
+			if opt.set {
+				opts.WriteByte(opt.name)
+			}
+		}
+
+		switch {
+		case opts.Len() == 1:
+			warnings = append(warnings, fmt.Sprintf("option '-%s' is ignored", opts.String()))
+		case opts.Len() > 1:
+			warnings = append(warnings, fmt.Sprintf("options '-%s' are ignored", opts.String()))
+		}
+		if unused.Reverse && lastResort {
+			warnings = append(warnings, "option '-r' only applies to last-resort comparison")
+		}
+	}
+
+	if numeric {
+		warnings = append(warnings, "numbers use '.' as a decimal point and no thousands separator")
+	}
+	return warnings
+}
+
+// validate проверяет, что выбран не более чем один способ сравнения
+func (o Ordering) validate() error {
+	var modes []string
+	if o.Numeric {
+		modes = append(modes, "-n")
+	}
+	if o.GeneralNumeric {
+		modes = append(modes, "-g")
+	}
+	if o.HumanNumeric {
+		modes = append(modes, "-h")
+	}
+	if o.Month {
+		modes = append(modes, "-M")
+	}
+	// Как в GNU sort, -V, -R, -d и -i несовместимы с числовыми сравнениями,
+	// но допускаются вместе друг с другом
+	switch {
+	case o.Version:
+		modes = append(modes, "-V")
+	case o.Random:
+		modes = append(modes, "-R")
+	case o.Dictionary:
+		modes = append(modes, "-d")
+	case o.IgnoreNonPrinting:
+		modes = append(modes, "-i")
+	}
+
+	if len(modes) > 1 {
+		return fmt.Errorf("conflicting options: %s and %s", modes[0], modes[1])
+	}
+	return nil
+}
+
+// sizeMultipliers — суффиксы размера для -S, как в GNU sort; регистр у K, M, G, T, P и E
+// не важен. Число без суффикса задано в KiB
+var sizeMultipliers = map[byte]int64{
+	'b': 1,
+	'K': 1 << 10, 'k': 1 << 10,
+	'M': 1 << 20, 'm': 1 << 20,
+	'G': 1 << 30, 'g': 1 << 30,
+	'T': 1 << 40, 't': 1 << 40,
+	'P': 1 << 50, 'p': 1 << 50,
+	'E': 1 << 60, 'e': 1 << 60,
+}
+
+// parseBufferSize разбирает размер буфера -S: 500M, 2G, 1024 (KiB) или 25% памяти
+func parseBufferSize(val string) (int64, error) {
+	digits := strings.TrimRight(val, "bKkMmGgTtPpEe%")
+	if len(val)-len(digits) > 1 {
+		return 0, fmt.Errorf("invalid buffer size: %s", val)
+	}
+
+	// ParseFloat принимает и экспоненту, и inf, а размер задается только цифрами
+	if strings.Trim(digits, "0123456789.") != "" {
+		return 0, fmt.Errorf("invalid buffer size: %s", val)
+	}
+	n, err := strconv.ParseFloat(digits, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid buffer size: %s", val)
+	}
+
+	suffix := byte('K')
+	if len(digits) < len(val) {
+		suffix = val[len(val)-1]
+	}
+
+	if suffix == '%' {
+		if n > 100 {
+			return 0, fmt.Errorf("invalid buffer size: %s", val)
+		}
+		total, err := physicalMemory()
+		if err != nil {
+			return 0, fmt.Errorf("buffer size %s: %v", val, err)
+		}
+		return int64(float64(total) * n / 100), nil
+	}
+
+	size := n * float64(sizeMultipliers[suffix])
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("buffer size too large: %s", val)
+	}
+	return int64(size), nil
+}
+
+// physicalMemory возвращает объем физической памяти из /proc/meminfo
+func physicalMemory() (int64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, fmt.Errorf("cannot determine physical memory: %v", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("cannot determine physical memory: %v", err)
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot determine physical memory: MemTotal not found")
+}
+
+// readRandomSource получает соль для -R из первых байтов файла
+func readRandomSource(path string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var buf [8]byte
+	if _, err := io.ReadFull(file, buf[:]); err != nil {
+		return 0, fmt.Errorf("%s: not enough random bytes: %v", path, err)
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// parseSeparator проверяет разделитель полей -t, допускается один символ или \0
+func parseSeparator(val string) (string, error) {
+	if val == `\0` {
+		return "\x00", nil
+	}
+	if val == "" {
+		return "", fmt.Errorf("empty tab")
+	}
+	if utf8.RuneCountInString(val) != 1 {
+		return "", fmt.Errorf("multi-character tab %q", val)
+	}
+	return val, nil
+}
+
+// parseKeySpec разбирает определение ключа вида F[.C][OPTS][,F[.C][OPTS]]
+func parseKeySpec(spec string) (KeySpec, error) {
+	var key KeySpec
+
+	startPos, endPos, hasEnd := strings.Cut(spec, ",")
+
+	field, char, opts, err := parseKeyPosition(startPos)
+	if err != nil {
+		return key, fmt.Errorf("invalid key specification %q: %v", spec, err)
+	}
+	if field == 0 {
+		return key, fmt.Errorf("invalid key specification %q: field number is zero", spec)
+	}
+	if char == 0 {
+		if strings.Contains(startPos, ".") {
+			return key, fmt.Errorf("invalid key specification %q: character offset is zero", spec)
+		}
+		char = 1
+	}
+	key.StartField, key.StartChar = field, char
+	if err := key.Ordering.setModifiers(opts); err != nil {
+		return key, fmt.Errorf("invalid key specification %q: %v", spec, err)
+	}
+
+	if hasEnd {
+		field, char, opts, err = parseKeyPosition(endPos)
+		if err != nil {
+			return key, fmt.Errorf("invalid key specification %q: %v", spec, err)
+		}
+		if field == 0 {
+			return key, fmt.Errorf("invalid key specification %q: field number is zero", spec)
+		}
+		key.EndField, key.EndChar = field, char
+		if err := key.Ordering.setModifiers(opts); err != nil {
+			return key, fmt.Errorf("invalid key specification %q: %v", spec, err)
+		}
+	}
+
+	return key, nil
+}
+
+// parseKeyPosition разбирает позицию F[.C][OPTS] и возвращает поле, символ и модификаторы
+func parseKeyPosition(pos string) (int, int, string, error) {
+	digits := func(s string) (int, string, error) {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			return 0, s, fmt.Errorf("number expected in %q", pos)
+		}
+		n, err := strconv.Atoi(s[:end])
+		return n, s[end:], err
+	}
+
+	field, rest, err := digits(pos)
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	char := 0
+	if strings.HasPrefix(rest, ".") {
+		char, rest, err = digits(rest[1:])
+		if err != nil {
+			return 0, 0, "", err
+		}
+	}
+
+	return field, char, rest, nil
+}
+
+// setModifiers включает модификаторы ключа, перечисленные буквами
+func (o *Ordering) setModifiers(opts string) error {
+	for _, f := range opts {
+		switch f {
+		case 'n':
+			o.Numeric = true
+		case 'r':
+			o.Reverse = true
+		case 'M':
+			o.Month = true
+		case 'h':
+			o.HumanNumeric = true
+		case 'b':
+			o.IgnoreBlanks = true
+		case 'f':
+			o.FoldCase = true
+		case 'd':
+			o.Dictionary = true
+		case 'i':
+			o.IgnoreNonPrinting = true
+		case 'g':
+			o.GeneralNumeric = true
+		case 'V':
+			o.Version = true
+		case 'R':
+			o.Random = true
+		default:
+			return fmt.Errorf("unknown modifier %q", f)
+		}
+	}
+	return nil
+}
+
 // Usage создает комментарии для терминала
 func Usage() {
 	fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] [FILE...]\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "Sort lines of text files\n\n")
 	fmt.Fprintf(os.Stderr, "Mandatory options (like GNU sort):\n")
-	fmt.Fprintf(os.Stderr, "  -k N          sort by column N (1-based)\n")
+	fmt.Fprintf(os.Stderr, "  -k KEYDEF     sort by key KEYDEF = F[.C][OPTS][,F[.C][OPTS]], may be repeated\n")
 	fmt.Fprintf(os.Stderr, "  -n            sort numerically\n")
 	fmt.Fprintf(os.Stderr, "  -r            reverse sort order\n")
 	fmt.Fprintf(os.Stderr, "  -u            output only unique lines\n")
 	fmt.Fprintf(os.Stderr, "\nAdditional options:\n")
 	fmt.Fprintf(os.Stderr, "  -M            sort by month names\n")
-	fmt.Fprintf(os.Stderr, "  -b            ignore trailing blanks\n")
-	fmt.Fprintf(os.Stderr, "  -c            check if input is sorted\n")
+	fmt.Fprintf(os.Stderr, "  -b            ignore leading blanks\n")
+	fmt.Fprintf(os.Stderr, "  -c            check if input is sorted, report the first disorder\n")
+	fmt.Fprintf(os.Stderr, "  -C            like -c, but do not report the first disorder\n")
+	fmt.Fprintf(os.Stderr, "  -m            merge already sorted files; do not sort\n")
+	fmt.Fprintf(os.Stderr, "  -o FILE       write result to FILE instead of standard output (FILE may be an input)\n")
+	fmt.Fprintf(os.Stderr, "  -S SIZE       use SIZE for main memory buffer: 500M, 2G, 25%% (default unit K)\n")
+	fmt.Fprintf(os.Stderr, "  -T DIR        use DIR for temporary files, may be repeated to spread them\n")
+	fmt.Fprintf(os.Stderr, "  --batch-size=NMERGE  merge at most NMERGE inputs at once; for more use temp files\n")
+	fmt.Fprintf(os.Stderr, "  --compress-program=PROG  compress temporary files with built-in PROG: gzip or flate\n")
+	fmt.Fprintf(os.Stderr, "  -s            stable sort: disable last-resort comparison of whole lines\n")
+	fmt.Fprintf(os.Stderr, "  -z            line delimiter is NUL, not newline\n")
+	fmt.Fprintf(os.Stderr, "  -t SEP        use SEP instead of blank-to-non-blank transition as field separator\n")
 	fmt.Fprintf(os.Stderr, "  -h            sort by human-readable numbers\n")
-	fmt.Fprintf(os.Stderr, "\nCombined flags are supported: -nr, -nru, etc.\n")
+	fmt.Fprintf(os.Stderr, "  -g            compare according to general numerical value (1e3, +5, inf, nan)\n")
+	fmt.Fprintf(os.Stderr, "  -V            natural sort of version numbers within text\n")
+	fmt.Fprintf(os.Stderr, "  -R            shuffle, but group identical keys\n")
+	fmt.Fprintf(os.Stderr, "  --random-source=FILE  get the shuffle seed from FILE\n")
+	fmt.Fprintf(os.Stderr, "  --seed=N      use N as the shuffle seed for reproducible -R\n")
+	fmt.Fprintf(os.Stderr, "  -f            fold lower case to upper case characters\n")
+	fmt.Fprintf(os.Stderr, "  -d            consider only blanks and alphanumeric characters\n")
+	fmt.Fprintf(os.Stderr, "  -i            consider only printable characters\n")
+	fmt.Fprintf(os.Stderr, "  --repeated    output one line of each group of lines with equal keys, only groups of two or more\n")
+	fmt.Fprintf(os.Stderr, "  --count       prefix one line of each group of lines with equal keys by the group size\n")
+	fmt.Fprintf(os.Stderr, "  --top=N       output only the first N records in sort order (alias --head)\n")
+	fmt.Fprintf(os.Stderr, "  --bottom=N    output only the last N records in sort order (alias --tail)\n")
+	fmt.Fprintf(os.Stderr, "  --debug       annotate the part of the line used to sort, warn about questionable options\n")
+	fmt.Fprintf(os.Stderr, "  --csv         parse records as CSV (-t TAB for TSV): quoted fields may hold separators and newlines\n")
+	fmt.Fprintf(os.Stderr, "  --header=N    output the first N records first, unsorted\n")
+	fmt.Fprintf(os.Stderr, "  --parallel=N  sort with N goroutines (default GOMAXPROCS)\n")
+	fmt.Fprintf(os.Stderr, "  --collate     compare text by Unicode rules: letters by alphabet, accents and case last\n")
+	fmt.Fprintf(os.Stderr, "  --help        display this help and exit\n")
+	fmt.Fprintf(os.Stderr, "\nKey modifiers OPTS: b, d, f, g, h, i, M, n, R, r, V (override global options for that key)\n")
+	fmt.Fprintf(os.Stderr, "Combined flags are supported: -nr, -nru, -k2,2n -k1,1r, etc.\n")
 }
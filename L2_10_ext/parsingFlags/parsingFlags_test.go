@@ -0,0 +1,66 @@
+package parsingflags
+
+import "testing"
+
+// TestParseBufferSize проверяет суффиксы -S: без суффикса размер задан в KiB, как в GNU sort
+func TestParseBufferSize(t *testing.T) {
+	tests := []struct {
+		val  string
+		want int64
+	}{
+		{val: "100", want: 100 << 10},
+		{val: "512b", want: 512},
+		{val: "4K", want: 4 << 10},
+		{val: "4k", want: 4 << 10},
+		{val: "500M", want: 500 << 20},
+		{val: "500m", want: 500 << 20},
+		{val: "2G", want: 2 << 30},
+		{val: "2g", want: 2 << 30},
+		{val: "1T", want: 1 << 40},
+		{val: "1t", want: 1 << 40},
+		{val: "1P", want: 1 << 50},
+		{val: "1p", want: 1 << 50},
+		{val: "1E", want: 1 << 60},
+		{val: "1e", want: 1 << 60},
+		{val: "1.5M", want: 3 << 19},
+	}
+
+	for _, test := range tests {
+		t.Run(test.val, func(t *testing.T) {
+			got, err := parseBufferSize(test.val)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+// TestParseBufferSizePercent проверяет размер в процентах физической памяти
+func TestParseBufferSizePercent(t *testing.T) {
+	total, err := physicalMemory()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	got, err := parseBufferSize("25%")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := total / 4; got != want {
+		t.Errorf("got %d, want %d", got, want)
+	}
+}
+
+// TestParseBufferSizeInvalid проверяет отказ на некорректных размерах
+func TestParseBufferSizeInvalid(t *testing.T) {
+	for _, val := range []string{"", "M", "0", "-1K", "10MK", "1x", "101%", "abc", "8E", "1Z", "1e5", "inf"} {
+		t.Run(val, func(t *testing.T) {
+			if got, err := parseBufferSize(val); err == nil {
+				t.Errorf("got %d, want error", got)
+			}
+		})
+	}
+}
@@ -2,57 +2,165 @@ package readinput
 
 import (
 	"bufio"
-	"errors"
+	"bytes"
+	"encoding/csv"
+	"io"
 	"os"
 )
 
-const (
-	MaxSize = 8 * 1024 * 1024 * 1024
-)
-
-var ErrLargeFile = errors.New("large file")
+// Stdin — имя операнда, которое, как в sort, означает стандартный ввод
+const Stdin = "-"
 
-// ReadInput читает строки из файлов или stdin и добавляет в массив
-func ReadInput(files []string) ([]string, error) {
-	var lines []string
+// Open открывает файл входа. Для операнда "-" возвращается stdin, который Close не закрывает
+func Open(name string) (io.ReadCloser, error) {
+	if name == Stdin {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
 
+// ForEachLine читает записи файлов или stdin, не загружая их целиком в память,
+// и передает каждую запись без разделителя в fn. Длина записи не ограничена.
+// Операнд "-" читается из stdin. Возвращает true, если последняя запись входа
+// завершалась разделителем
+func ForEachLine(files []string, delim byte, comma rune, fn func(line string) error) (bool, error) {
 	if len(files) == 0 {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-		}
-		return lines, scanner.Err()
+		return ReadRecords(os.Stdin, delim, comma, fn)
 	}
 
-	var totalSize int64
+	terminated := true
 	for _, filename := range files {
-		fileInfo, err := os.Stat(filename)
+		file, err := Open(filename)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		totalSize += fileInfo.Size()
-	}
 
-	if totalSize > MaxSize {
-		return nil, ErrLargeFile
+		hasRecords := false
+		fileTerminated, err := ReadRecords(file, delim, comma, func(line string) error {
+			hasRecords = true
+			return fn(line)
+		})
+		file.Close()
+
+		if err != nil {
+			return false, err
+		}
+		// Пустой файл не меняет признак, полученный от предыдущих
+		if hasRecords {
+			terminated = fileTerminated
+		}
 	}
 
-	for _, filename := range files {
-		file, err := os.Open(filename)
+	return terminated, nil
+}
+
+// ReadRecords передает в fn все записи одного потока. Символ '\r' перед '\n'
+// остается частью записи, поэтому строки с CRLF выводятся без изменений
+func ReadRecords(r io.Reader, delim byte, comma rune, fn func(line string) error) (bool, error) {
+	reader := NewRecordReader(r, delim, comma)
+	terminated := true
+	for {
+		line, lineTerminated, err := reader.Next()
+		if err == io.EOF {
+			return terminated, nil
+		}
 		if err != nil {
-			return nil, err
+			return false, err
+		}
+		terminated = lineTerminated
+		if err := fn(line); err != nil {
+			return false, err
+		}
+	}
+}
+
+// RecordReader читает записи потока по одной. Обычно запись — текст до байта delim,
+// а при ненулевом comma — CSV-запись, границы которой находит encoding/csv: поле
+// в кавычках может содержать перевод строки, и такая запись занимает несколько строк.
+// Текст записи возвращается без изменений, кавычки не снимаются
+type RecordReader struct {
+	reader *bufio.Reader
+	delim  byte
+
+	csv    *csv.Reader
+	raw    *bytes.Buffer // текст, прочитанный csv.Reader, но еще не выданный
+	offset int64         // позиция конца последней разобранной CSV-записи
+	next   []byte        // исходный текст разобранной записи с предшествующими пустыми строками
+	eof    bool
+}
+
+// NewRecordReader создает читатель записей r; comma = 0 отключает разбор CSV.
+// Кавычка внутри поля без кавычек считается обычным символом (LazyQuotes), как и
+// при разборе полей ключей в sorting, поэтому такие записи не прерывают сортировку
+func NewRecordReader(r io.Reader, delim byte, comma rune) *RecordReader {
+	if comma == 0 {
+		return &RecordReader{reader: bufio.NewReader(r), delim: delim}
+	}
+
+	raw := &bytes.Buffer{}
+	reader := csv.NewReader(io.TeeReader(r, raw))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+	return &RecordReader{delim: '\n', csv: reader, raw: raw}
+}
+
+// Next возвращает следующую запись без разделителя и признак того, что она
+// завершалась разделителем. В конце потока возвращает io.EOF
+func (r *RecordReader) Next() (string, bool, error) {
+	if r.csv != nil {
+		return r.nextCSV()
+	}
+
+	line, err := r.reader.ReadString(r.delim)
+	if err == io.EOF {
+		if line == "" {
+			return "", false, io.EOF
+		}
+		return line, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return line[:len(line)-1], true, nil
+}
+
+// nextCSV выделяет исходный текст очередной CSV-записи по смещению, которое сообщает
+// csv.Reader. Пустые строки csv.Reader пропускает, поэтому они выдаются отдельно как пустые записи
+func (r *RecordReader) nextCSV() (string, bool, error) {
+	for len(r.next) == 0 {
+		if r.eof {
+			return "", false, io.EOF
 		}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
+		_, err := r.csv.Read()
+		if err == io.EOF {
+			// После последней записи могут остаться только пустые строки
+			r.next = bytes.Clone(r.raw.Bytes())
+			r.eof = true
+			continue
+		}
+		if err != nil {
+			return "", false, err
 		}
-		file.Close()
 
-		if err := scanner.Err(); err != nil {
-			return nil, err
+		end := r.csv.InputOffset()
+		r.next = bytes.Clone(r.raw.Next(int(end - r.offset)))
+		r.offset = end
+	}
+
+	for _, blank := range []string{"\n", "\r\n"} {
+		if rest, ok := bytes.CutPrefix(r.next, []byte(blank)); ok {
+			r.next = rest
+			return "", true, nil
 		}
 	}
 
-	return lines, nil
+	record := r.next
+	r.next = nil
+	if text, ok := bytes.CutSuffix(record, []byte{'\n'}); ok {
+		return string(text), true, nil
+	}
+	return string(record), false, nil
 }
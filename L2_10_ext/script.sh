@@ -39,13 +39,13 @@ else
     echo "✗ Результаты различаются"
 fi
 
-echo -e "\n=== Тест 2: Числовая сортировка по колонке 2 (-k 2 -n) ==="
+echo -e "\n=== Тест 2: Числовая сортировка по колонке 2 (-k 2,2 -n) ==="
 echo "GNU sort:"
 sort -k 2,2 -n test_input.txt > sort_output2.txt
 cat sort_output2.txt
 
 echo -e "\nMy sort:"
-go run my_sort.go -k 2 -n test_input.txt > my_sort_output2.txt
+go run my_sort.go -k 2,2 -n test_input.txt > my_sort_output2.txt
 cat my_sort_output2.txt
 
 echo -e "\nСравнение:"
@@ -87,13 +87,13 @@ else
     echo "✗ Результаты различаются"
 fi
 
-echo -e "\n=== Тест 5: Комбинированные флаги (-k 2 -nr) ==="
+echo -e "\n=== Тест 5: Комбинированные флаги (-k 2,2 -nr) ==="
 echo "GNU sort:"
 sort -k 2,2 -nr test_input.txt > sort_output5.txt
 cat sort_output5.txt
 
 echo -e "\nMy sort:"
-go run my_sort.go -k 2 -nr test_input.txt > my_sort_output5.txt
+go run my_sort.go -k 2,2 -nr test_input.txt > my_sort_output5.txt
 cat my_sort_output5.txt
 
 echo -e "\nСравнение:"
@@ -103,13 +103,13 @@ else
     echo "✗ Результаты различаются"
 fi
 
-echo -e "\n=== Тест 6: Сортировка по месяцам (-k 3 -M) ==="
+echo -e "\n=== Тест 6: Сортировка по месяцам (-k 3,3 -M) ==="
 echo "GNU sort:"
 sort -k 3,3 -M test_input.txt > sort_output6.txt
 cat sort_output6.txt
 
 echo -e "\nMy sort:"
-go run my_sort.go -k 3 -M test_input.txt > my_sort_output6.txt
+go run my_sort.go -k 3,3 -M test_input.txt > my_sort_output6.txt
 cat my_sort_output6.txt
 
 echo -e "\nСравнение:"
@@ -119,13 +119,13 @@ else
     echo "✗ Результаты различаются"
 fi
 
-echo -e "\n=== Тест 7: Человеко-читаемые числа (-k 2 -h) ==="
+echo -e "\n=== Тест 7: Человеко-читаемые числа (-k 2,2 -h) ==="
 echo "GNU sort:"
 sort -k 2,2 -h test_input.txt > sort_output7.txt
 cat sort_output7.txt
 
 echo -e "\nMy sort:"
-go run my_sort.go -k 2 -h test_input.txt > my_sort_output7.txt
+go run my_sort.go -k 2,2 -h test_input.txt > my_sort_output7.txt
 cat my_sort_output7.txt
 
 echo -e "\nСравнение:"
@@ -135,7 +135,126 @@ else
     echo "✗ Результаты различаются"
 fi
 
-echo -e "\n=== Тест 8: Проверка сортировки (-c) ==="
+echo -e "\n=== Тест 8: Несколько ключей (-k 2,2h -k 1,1r) ==="
+echo "GNU sort:"
+sort -k 2,2h -k 1,1r test_input.txt > sort_output8.txt
+cat sort_output8.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -k 2,2h -k 1,1r test_input.txt > my_sort_output8.txt
+cat my_sort_output8.txt
+
+echo -e "\nСравнение:"
+if diff sort_output8.txt my_sort_output8.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 9: Стабильная сортировка (-s -k 2,2n) ==="
+echo "GNU sort:"
+sort -s -k 2,2n test_input.txt > sort_output9.txt
+cat sort_output9.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -s -k 2,2n test_input.txt > my_sort_output9.txt
+cat my_sort_output9.txt
+
+echo -e "\nСравнение:"
+if diff sort_output9.txt my_sort_output9.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 10: Слияние отсортированных файлов (-m -k 2,2n) ==="
+head -7 test_input.txt | sort -k 2,2n > merge_part1.txt
+tail -7 test_input.txt | sort -k 2,2n > merge_part2.txt
+echo "GNU sort:"
+sort -m -k 2,2n merge_part1.txt merge_part2.txt > sort_output10.txt
+cat sort_output10.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -m -k 2,2n merge_part1.txt merge_part2.txt > my_sort_output10.txt
+cat my_sort_output10.txt
+
+echo -e "\nСравнение:"
+if diff sort_output10.txt my_sort_output10.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 11: Сортировка файла на месте (-o) ==="
+cp test_input.txt sort_inplace11.txt
+cp test_input.txt my_sort_inplace11.txt
+echo "GNU sort:"
+sort -o sort_inplace11.txt sort_inplace11.txt
+cat sort_inplace11.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -o my_sort_inplace11.txt my_sort_inplace11.txt
+cat my_sort_inplace11.txt
+
+echo -e "\nСравнение:"
+if diff sort_inplace11.txt my_sort_inplace11.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 12: Записи, разделенные нулевым байтом (-z) ==="
+tr '\n' '\0' < test_input.txt > zero_input12.txt
+echo "GNU sort:"
+sort -z zero_input12.txt | tr '\0' '\n' > sort_output12.txt
+cat sort_output12.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -z zero_input12.txt | tr '\0' '\n' > my_sort_output12.txt
+cat my_sort_output12.txt
+
+echo -e "\nСравнение:"
+if diff sort_output12.txt my_sort_output12.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 13: Сортировка версий (-V) ==="
+printf 'v1.10\nv1.9\nv1.9~rc1\nv2.0\nv1.2.3\nv1.2.10\n' > versions13.txt
+echo "GNU sort:"
+sort -V versions13.txt > sort_output13.txt
+cat sort_output13.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -V versions13.txt > my_sort_output13.txt
+cat my_sort_output13.txt
+
+echo -e "\nСравнение:"
+if diff sort_output13.txt my_sort_output13.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 14: Общая числовая сортировка (-g) ==="
+printf '1e3\n+5\n-inf\ninf\nnan\nabc\n2.5E-1\n-3\n' > general14.txt
+echo "GNU sort:"
+sort -g general14.txt > sort_output14.txt
+cat sort_output14.txt
+
+echo -e "\nMy sort:"
+go run my_sort.go -g general14.txt > my_sort_output14.txt
+cat my_sort_output14.txt
+
+echo -e "\nСравнение:"
+if diff sort_output14.txt my_sort_output14.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 15: Проверка сортировки (-c) ==="
 echo "GNU sort:"
 sort -c test_input.txt 2>&1 || true
 
@@ -0,0 +1,60 @@
+package sorting
+
+import (
+	"context"
+	"fmt"
+	"io"
+	parsingflags "main/parsingFlags"
+	readinput "main/readInput"
+)
+
+// DisorderError описывает первую запись, нарушающую порядок сортировки
+type DisorderError struct {
+	File string // имя файла, "-" для stdin
+	Line int    // номер записи, начиная с 1
+	Text string
+}
+
+func (e *DisorderError) Error() string {
+	return fmt.Sprintf("sort: %s:%d: disorder: %s", e.File, e.Line, e.Text)
+}
+
+// CheckSorted потоково проверяет, что записи из r упорядочены согласно config.
+// В памяти хранится только предыдущая запись. При нарушении порядка возвращает
+// *DisorderError с именем файла "-", которое вызывающий может заменить.
+// Первые --header записей не проверяются
+func CheckSorted(r io.Reader, config *parsingflags.Config) error {
+	return checkSorted(context.Background(), r, plain(config))
+}
+
+// checkSorted проверяет порядок как CheckSorted, прерываясь при отмене ctx
+// и учитывая пользовательские ключи
+func checkSorted(ctx context.Context, r io.Reader, config *settings) error {
+	var prev Record
+	lineNum := 0
+
+	_, err := readinput.ReadRecords(r, config.Terminator(), config.CSVComma(), func(line string) error {
+		lineNum++
+		if lineNum%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if lineNum <= config.Header {
+			return nil
+		}
+		record := newRecord(line, config)
+
+		if lineNum > config.Header+1 {
+			result := compareRecords(&prev, &record, config)
+			// С -u равные соседние записи тоже считаются нарушением порядка
+			if result > 0 || (config.Unique && result == 0) {
+				return &DisorderError{File: "-", Line: lineNum, Text: line}
+			}
+		}
+
+		prev = record
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,73 @@
+package sorting
+
+import (
+	"errors"
+	parsingflags "main/parsingFlags"
+	"strings"
+	"testing"
+)
+
+// TestCheckSorted проверяет -c: текст диагностики, равных соседей при -u и пропуск --header
+func TestCheckSorted(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		config *parsingflags.Config
+		want   string // текст DisorderError, пустая строка — вход отсортирован
+	}{
+		{
+			name:   "sorted",
+			input:  "a\nb\nb\nc\n",
+			config: &parsingflags.Config{},
+		},
+		{
+			name:   "disorder",
+			input:  "a\nc\nb\nd\n",
+			config: &parsingflags.Config{},
+			want:   "sort: -:3: disorder: b",
+		},
+		{
+			name:   "unique equal neighbours",
+			input:  "a\nb\nb\nc\n",
+			config: &parsingflags.Config{Unique: true},
+			want:   "sort: -:3: disorder: b",
+		},
+		{
+			name:   "unique equal keys",
+			input:  "1 x\n01 y\n",
+			config: &parsingflags.Config{Unique: true, Ordering: parsingflags.Ordering{Numeric: true}},
+			want:   "sort: -:2: disorder: 01 y",
+		},
+		{
+			name:   "header skipped",
+			input:  "name\nz\na\nb\n",
+			config: &parsingflags.Config{Header: 2},
+		},
+		{
+			name:   "disorder after header",
+			input:  "name\nb\na\n",
+			config: &parsingflags.Config{Header: 1},
+			want:   "sort: -:3: disorder: a",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckSorted(strings.NewReader(test.input), test.config)
+			if test.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var disorder *DisorderError
+			if !errors.As(err, &disorder) {
+				t.Fatalf("got %v, want *DisorderError", err)
+			}
+			if got := disorder.Error(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package sorting
+
+import (
+	"cmp"
+	parsingflags "main/parsingFlags"
+	"unicode"
+)
+
+// Классы символов при сравнении по правилам Unicode: пробелы и знаки идут перед цифрами,
+// цифры перед буквами, буквы упорядочиваются по письменностям
+const (
+	classOther = iota
+	classDigit
+	classLatin
+	classGreek
+	classCyrillic
+	classLetter
+)
+
+// baseLetters сопоставляет буквам с диакритикой их базовую букву. Это упрощение
+// Unicode Collation Algorithm без таблиц CLDR и нормализации, и оно не покрывает:
+//   - буквы, раскрывающиеся в несколько (ß как ss, æ как ae, œ как oe) — они
+//     сравниваются как отдельные буквы после z;
+//   - составные последовательности с комбинируемыми знаками (e + U+0301) — они
+//     не приравниваются к готовой букве é;
+//   - диакритику вне таблицы ниже и локальные правила алфавитов (å в шведском
+//     после z, ch в чешском и т.п.); письменности идут в фиксированном порядке:
+//     латиница, греческий, кириллица, остальные буквы
+var baseLetters = map[rune]rune{}
+
+func init() {
+	variants := map[rune]string{
+		'a': "àáâãäåāăą",
+		'c': "çćĉċč",
+		'd': "ďđ",
+		'e': "èéêëēĕėęě",
+		'g': "ĝğġģ",
+		'h': "ĥħ",
+		'i': "ìíîïĩīĭįı",
+		'j': "ĵ",
+		'k': "ķ",
+		'l': "ĺļľŀł",
+		'n': "ñńņňŉ",
+		'o': "òóôõöøōŏő",
+		'r': "ŕŗř",
+		's': "śŝşšș",
+		't': "ţťŧț",
+		'u': "ùúûüũūŭůűų",
+		'w': "ŵ",
+		'y': "ýÿŷ",
+		'z': "źżž",
+		'е': "ё",
+	}
+	for base, letters := range variants {
+		for _, r := range letters {
+			baseLetters[r] = base
+		}
+	}
+}
+
+// needsRunes сообщает, требует ли текстовое сравнение предварительной обработки ключа
+func needsRunes(ordering parsingflags.Ordering) bool {
+	return ordering.FoldCase || ordering.Dictionary || ordering.IgnoreNonPrinting || ordering.Collate
+}
+
+// filterRunes убирает символы, игнорируемые по -d и -i, и приводит регистр по -f
+func filterRunes(s string, ordering parsingflags.Ordering) []rune {
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		if ordering.Dictionary && !(r == ' ' || r == '\t' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			continue
+		}
+		if ordering.IgnoreNonPrinting && !unicode.IsPrint(r) {
+			continue
+		}
+		if ordering.FoldCase {
+			r = unicode.ToUpper(r)
+		}
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// collate сравнивает руны в три уровня, как принято в словарях: сначала по базовым
+// буквам, затем по диакритике и только потом по регистру (строчные раньше прописных)
+func collate(a, b []rune) int {
+	// Первый уровень: класс символа и базовая буква без диакритики и регистра
+	for i := 0; i < len(a) && i < len(b); i++ {
+		classA, baseA := primaryWeight(a[i])
+		classB, baseB := primaryWeight(b[i])
+		if classA != classB {
+			return cmp.Compare(classA, classB)
+		}
+		if baseA != baseB {
+			return cmp.Compare(int(baseA), int(baseB))
+		}
+	}
+	if len(a) != len(b) {
+		return cmp.Compare(len(a), len(b))
+	}
+
+	// Второй уровень: буква с диакритикой идет после базовой
+	for i := range a {
+		lowerA, lowerB := unicode.ToLower(a[i]), unicode.ToLower(b[i])
+		if lowerA != lowerB {
+			return cmp.Compare(int(lowerA), int(lowerB))
+		}
+	}
+
+	// Третий уровень: строчная буква идет раньше прописной
+	for i := range a {
+		if a[i] != b[i] {
+			return cmp.Compare(int(b[i]), int(a[i]))
+		}
+	}
+	return 0
+}
+
+// primaryWeight возвращает класс символа и его базовую форму
+func primaryWeight(r rune) (int, rune) {
+	lower := unicode.ToLower(r)
+	if base, ok := baseLetters[lower]; ok {
+		lower = base
+	}
+
+	switch {
+	case unicode.IsDigit(r):
+		return classDigit, lower
+	case unicode.Is(unicode.Latin, r):
+		return classLatin, lower
+	case unicode.Is(unicode.Greek, r):
+		return classGreek, lower
+	case unicode.Is(unicode.Cyrillic, r):
+		return classCyrillic, lower
+	case unicode.IsLetter(r):
+		return classLetter, lower
+	default:
+		return classOther, lower
+	}
+}
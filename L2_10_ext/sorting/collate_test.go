@@ -0,0 +1,64 @@
+package sorting
+
+import (
+	parsingflags "main/parsingFlags"
+	"slices"
+	"testing"
+)
+
+// TestCollateSort проверяет --collate и текстовые модификаторы -f, -d, -i
+func TestCollateSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		ordering parsingflags.Ordering
+		input    []string
+		want     []string
+	}{
+		{
+			name:     "mixed russian and english inventory",
+			ordering: parsingflags.Ordering{Collate: true},
+			input:    []string{"яблоко", "Apple", "ёлка", "банан", "apple", "Ель", "Banana", "2 штуки", "10 штук", "éclair", "eclair", "zebra"},
+			want:     []string{"10 штук", "2 штуки", "apple", "Apple", "Banana", "eclair", "éclair", "zebra", "банан", "ёлка", "Ель", "яблоко"},
+		},
+		{
+			name:     "byte order without collate",
+			ordering: parsingflags.Ordering{},
+			input:    []string{"яблоко", "Apple", "ёлка", "банан", "apple", "Ель"},
+			want:     []string{"Apple", "apple", "Ель", "банан", "яблоко", "ёлка"},
+		},
+		{
+			name:     "accents sort after base letter, case last",
+			ordering: parsingflags.Ordering{Collate: true},
+			input:    []string{"Éte", "ete", "éte", "Ete", "etf"},
+			want:     []string{"ete", "Ete", "éte", "Éte", "etf"},
+		},
+		{
+			name:     "fold case",
+			ordering: parsingflags.Ordering{FoldCase: true},
+			input:    []string{"b", "A", "a", "B", "ж", "Е"},
+			want:     []string{"A", "a", "B", "b", "Е", "ж"},
+		},
+		{
+			name:     "dictionary order",
+			ordering: parsingflags.Ordering{Dictionary: true},
+			input:    []string{"a-c", "ab", "a c", "a.b"},
+			want:     []string{"a c", "a.b", "ab", "a-c"},
+		},
+		{
+			name:     "ignore non-printing",
+			ordering: parsingflags.Ordering{IgnoreNonPrinting: true},
+			input:    []string{"a\x01c", "ab", "a\x7fa"},
+			want:     []string{"a\x7fa", "ab", "a\x01c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &parsingflags.Config{Ordering: tt.ordering, Parallel: 1}
+			got := SortLines(tt.input, config)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SortLines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
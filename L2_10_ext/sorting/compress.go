@@ -0,0 +1,50 @@
+package sorting
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Встроенные методы сжатия временных файлов (--compress-program)
+const (
+	CompressGzip  = "gzip"
+	CompressFlate = "flate"
+)
+
+// nopWriteCloser позволяет писать без сжатия через тот же интерфейс
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newCompressWriter оборачивает запись чанка в сжатие выбранным методом.
+// Используется самый быстрый уровень: временные файлы живут недолго
+func newCompressWriter(w io.Writer, method string) (io.WriteCloser, error) {
+	switch method {
+	case "":
+		return nopWriteCloser{w}, nil
+	case CompressGzip:
+		return gzip.NewWriterLevel(w, gzip.BestSpeed)
+	case CompressFlate:
+		return flate.NewWriter(w, flate.BestSpeed)
+	default:
+		return nil, fmt.Errorf("unsupported compress program %q", method)
+	}
+}
+
+// newDecompressReader оборачивает чтение чанка в распаковку выбранным методом
+func newDecompressReader(r io.Reader, method string) (io.ReadCloser, error) {
+	switch method {
+	case "":
+		return io.NopCloser(r), nil
+	case CompressGzip:
+		return gzip.NewReader(r)
+	case CompressFlate:
+		return flate.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported compress program %q", method)
+	}
+}
@@ -0,0 +1,174 @@
+package sorting
+
+import (
+	"fmt"
+	parsingflags "main/parsingFlags"
+	writeoutput "main/writeOutput"
+	"strings"
+	"unicode/utf8"
+)
+
+// setDebugMarks включает пометки --debug в писателе итогового вывода.
+// С --count пометки сдвигаются на ширину счетчика в начале строки
+func setDebugMarks(writer *writeoutput.RecordWriter, config *settings) {
+	if !config.Debug {
+		return
+	}
+	writer.SetAnnotate(func(line string) string {
+		if !config.Count {
+			return debugMarks(line, config)
+		}
+
+		// Счетчик --count: пробелы, цифры и один пробел перед записью
+		prefix := skipBlanks(line, 0)
+		for prefix < len(line) && isDigit(line[prefix]) {
+			prefix++
+		}
+		prefix = min(prefix+1, len(line))
+
+		indent := strings.Repeat(" ", prefix)
+		marks := strings.TrimSuffix(debugMarks(line[prefix:], config), "\n")
+		return indent + strings.ReplaceAll(marks, "\n", "\n"+indent) + "\n"
+	})
+}
+
+// debugMarks возвращает пометки --debug для записи line: под каждой строкой ключа
+// подчеркнута выделенная часть и указано, как она разобрана. Последняя пометка
+// относится к сравнению строк целиком, если оно не отключено через -s, -u, --repeated или --count.
+// Тогда без -k и модификаторов порядка ключом отмечается вся строка
+func debugMarks(line string, config *settings) string {
+	var sb strings.Builder
+
+	switch {
+	case len(config.Keys) > 0:
+		text, fields := recordFields(line, config)
+		for _, key := range config.Keys {
+			start, end := keySpan(text, fields, key)
+			markKey(&sb, text, start, end, key.Ordering)
+		}
+	case hasGlobalKey(config.Ordering):
+		// Без -k модификаторы относятся к строке целиком, как в GNU sort
+		start := 0
+		if config.IgnoreBlanks {
+			start = skipBlanks(line, 0)
+		}
+		markKey(&sb, line, start, len(line), config.Ordering)
+	case config.Stable || config.Grouped():
+		// Сравнения строк целиком ниже не будет, а ключом служит вся строка
+		markSpan(&sb, line, 0, len(line), keyKind(config.Ordering))
+	}
+
+	if !config.Stable && !config.Grouped() {
+		markSpan(&sb, line, 0, len(line), "string")
+	}
+	return sb.String()
+}
+
+// hasGlobalKey сообщает, заданы ли без -k модификаторы, меняющие сравнение строки.
+// Одного -r недостаточно: он лишь обращает сравнение строк целиком
+func hasGlobalKey(ordering parsingflags.Ordering) bool {
+	ordering.Reverse = false
+	ordering.Collate = false
+	return ordering != parsingflags.Ordering{}
+}
+
+// markKey подчеркивает ключ line[start:end]. Для чисел и месяцев, как в GNU sort,
+// пропускаются ведущие пробелы и подчеркивается только разобранная часть
+func markKey(sb *strings.Builder, line string, start, end int, ordering parsingflags.Ordering) {
+	key := line[start:end]
+	numeric := ordering.Numeric || ordering.HumanNumeric || ordering.GeneralNumeric
+	if !numeric && !ordering.Month {
+		markSpan(sb, line, start, end, keyKind(ordering))
+		return
+	}
+
+	start = skipBlanks(line, start)
+	key = line[start:max(start, end)]
+
+	var width int
+	var kind string
+	switch {
+	case ordering.Month:
+		if month := MonthToNumber(key); month > 0 {
+			width = 3
+			kind = fmt.Sprintf("month %d", month)
+		}
+	case ordering.GeneralNumeric:
+		width = generalNumberLen(key)
+		num, class := parseGeneralNumeric(key)
+		kind = fmt.Sprintf("number %g", num)
+		if class == generalNaN {
+			kind = "number NaN"
+		}
+	default:
+		width = numberLen(key)
+		num, _ := parseNumericValueForSort(key[:width])
+		kind = fmt.Sprintf("number %g", num)
+		if ordering.HumanNumeric && width > 0 && width < len(key) && unitOrders[key[width]] > 0 {
+			width++
+			kind = "human size " + key[:width]
+		}
+	}
+
+	if width == 0 {
+		kind = "not a number, compared as 0"
+		switch {
+		case ordering.Month:
+			kind = "not a month, sorted first"
+		case ordering.GeneralNumeric:
+			kind = "not a number, sorted first"
+		}
+	}
+	markSpan(sb, line, start, start+width, kind)
+}
+
+// keyKind описывает сравнение текстового ключа
+func keyKind(ordering parsingflags.Ordering) string {
+	switch {
+	case ordering.Version:
+		return "version"
+	case ordering.Random:
+		return "random"
+	case ordering.Collate:
+		return "string, collated"
+	default:
+		return "string"
+	}
+}
+
+// markSpan выводит строку пометки: отступ до start и подчеркивание до end с описанием kind.
+// Пустой ключ отмечается, как в GNU sort, знаком ^ и текстом no match for key
+func markSpan(sb *strings.Builder, line string, start, end int, kind string) {
+	sb.WriteString(strings.Repeat(" ", utf8.RuneCountInString(line[:start])))
+	if end <= start {
+		fmt.Fprintf(sb, "^ no match for key (%s)\n", kind)
+		return
+	}
+	sb.WriteString(strings.Repeat("_", utf8.RuneCountInString(line[start:end])))
+	fmt.Fprintf(sb, " %s\n", kind)
+}
+
+// numberLen возвращает длину числа для -n и -h в начале s: знак минус, цифры
+// и дробная часть. Без цифр возвращает 0
+func numberLen(s string) int {
+	end := 0
+	if end < len(s) && s[end] == '-' {
+		end++
+	}
+	digits := 0
+	for end < len(s) && isDigit(s[end]) {
+		end++
+		digits++
+	}
+	if end < len(s) && s[end] == '.' {
+		end++
+		for end < len(s) && isDigit(s[end]) {
+			end++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0
+	}
+	return end
+}
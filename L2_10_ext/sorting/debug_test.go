@@ -0,0 +1,69 @@
+package sorting
+
+import (
+	parsingflags "main/parsingFlags"
+	"testing"
+)
+
+// TestDebugMarks сверяет пометки --debug с разметкой GNU sort --debug:
+// подчеркивается только разобранная часть числового ключа
+func TestDebugMarks(t *testing.T) {
+	numericKey := []parsingflags.KeySpec{{StartField: 2, StartChar: 1, EndField: 2, Ordering: parsingflags.Ordering{Numeric: true}}}
+	tests := []struct {
+		name   string
+		line   string
+		config *parsingflags.Config
+		want   string
+	}{
+		{
+			name:   "numeric key",
+			line:   "Jan 10",
+			config: &parsingflags.Config{Keys: numericKey},
+			want:   "    __ number 10\n______ string\n",
+		},
+		{
+			name:   "no match",
+			line:   "abc",
+			config: &parsingflags.Config{Keys: numericKey, Stable: true},
+			want:   "   ^ no match for key (not a number, compared as 0)\n",
+		},
+		{
+			name:   "human size",
+			line:   "  2K x",
+			config: &parsingflags.Config{Ordering: parsingflags.Ordering{HumanNumeric: true}},
+			want:   "  __ human size 2K\n______ string\n",
+		},
+		{
+			name:   "month",
+			line:   " feb",
+			config: &parsingflags.Config{Ordering: parsingflags.Ordering{Month: true}, Unique: true},
+			want:   " ___ month 2\n",
+		},
+		{
+			name:   "stable whole line",
+			line:   "b a",
+			config: &parsingflags.Config{Stable: true},
+			want:   "___ string\n",
+		},
+		{
+			name:   "count whole line",
+			line:   "b a",
+			config: &parsingflags.Config{Count: true, Ordering: parsingflags.Ordering{Reverse: true}},
+			want:   "___ string\n",
+		},
+		{
+			name:   "plain",
+			line:   "b a",
+			config: &parsingflags.Config{},
+			want:   "___ string\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := debugMarks(test.line, plain(test.config)); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,470 @@
+package sorting
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"io"
+	readinput "main/readInput"
+	writeoutput "main/writeOutput"
+)
+
+const (
+	ChunkSize    = 1 * 1024 * 1024 * 1024 // 1GB
+	MaxOpenFiles = 32
+
+	// cancelCheckInterval — через сколько записей проверяется отмена контекста
+	cancelCheckInterval = 1024
+
+	// minChunkSize — нижняя граница размера чанка, чтобы слишком малый -S
+	// не порождал по временному файлу на каждую строку
+	minChunkSize = 1 * 1024 * 1024 // 1MB
+
+	// lineOverhead учитывает заголовок строки, запись с разобранными ключами
+	// и элементы срезов при оценке памяти
+	lineOverhead = 128
+)
+
+type Chunk struct {
+	file   io.Closer
+	source io.Closer // распаковщик сжатого чанка
+	reader *readinput.RecordReader
+	record Record
+	index  int
+
+	// hasRecords и terminated описывают, была ли в файле хоть одна запись
+	// и завершалась ли последняя из них разделителем
+	hasRecords bool
+	terminated bool
+}
+
+// ChunkHeap упорядочивает чанки по текущей строке с помощью компаратора сортировки
+type ChunkHeap struct {
+	chunks []*Chunk
+	config *settings
+}
+
+func (h *ChunkHeap) Len() int { return len(h.chunks) }
+
+func (h *ChunkHeap) Less(i, j int) bool {
+	a, b := h.chunks[i], h.chunks[j]
+	if result := compareRecords(&a.record, &b.record, h.config); result != 0 {
+		return result < 0
+	}
+	// При равенстве строк раньше идет чанк с меньшим номером
+	return a.index < b.index
+}
+
+func (h *ChunkHeap) Swap(i, j int) { h.chunks[i], h.chunks[j] = h.chunks[j], h.chunks[i] }
+
+func (h *ChunkHeap) Push(x interface{}) {
+	h.chunks = append(h.chunks, x.(*Chunk))
+}
+
+func (h *ChunkHeap) Pop() interface{} {
+	old := h.chunks
+	n := len(old)
+	x := old[n-1]
+	h.chunks = old[0 : n-1]
+	return x
+}
+
+// recordSource передает записи входа в fn и сообщает, завершалась ли последняя из них разделителем
+type recordSource func(fn func(line string) error) (bool, error)
+
+// externalSort потоково читает вход, сортирует его частями во временные файлы
+// и сливает их. Если вход помещается в один чанк, сортировка идет в памяти.
+// Временные файлы удаляются и при ошибке, и при отмене ctx
+func externalSort(ctx context.Context, source recordSource, config *settings, outputWriter io.Writer) error {
+	temps := newTempFiles(config.TempDirs)
+	defer temps.removeAll()
+
+	// Разбиваем на чанки и сортируем их
+	input, err := createSortedChunks(ctx, source, config, temps)
+	if err != nil {
+		return err
+	}
+
+	writer := writeoutput.NewRecordWriter(outputWriter, config.Terminator())
+	if err := writeLines(writer, input.header); err != nil {
+		return err
+	}
+	// Записи заголовка не сравниваются, поэтому пометки --debug выводятся только для остальных
+	setDebugMarks(writer, config)
+	groups := newGroupWriter(writer, config)
+
+	// Если данные поместились в память, используем обычную сортировку
+	if len(input.files) == 0 {
+		if err := groups.addAll(sortRecords(input.last, config)); err != nil {
+			return err
+		}
+		if err := groups.flush(); err != nil {
+			return err
+		}
+		return writer.Finish(input.terminated)
+	}
+
+	// Промежуточными проходами сокращаем число чанков до --batch-size и сливаем оставшиеся
+	chunkFiles, _, err := reduceRuns(ctx, input.files, config.Compress, config, temps)
+	if err != nil {
+		return err
+	}
+	if _, err := mergeChunks(ctx, chunkFiles, config.Compress, config, groups); err != nil {
+		return err
+	}
+	if err := groups.flush(); err != nil {
+		return err
+	}
+	return writer.Finish(input.terminated)
+}
+
+// sortedInput — вход, разбитый на отсортированные части
+type sortedInput struct {
+	files      []string // временные файлы с отсортированными чанками
+	last       []string // весь вход, если он поместился в один чанк
+	header     []string // первые --header записей, которые не сортируются
+	terminated bool     // завершалась ли последняя запись входа разделителем
+}
+
+func estimateMemoryUsage(line string) int64 {
+	return int64(len(line)) + lineOverhead
+}
+
+// chunkSize возвращает объем памяти под один чанк: бюджет -S или ChunkSize по умолчанию
+func chunkSize(config *settings) int64 {
+	if config.BufferSize > 0 {
+		return max(config.BufferSize, minChunkSize)
+	}
+	return ChunkSize
+}
+
+// batchSize возвращает число файлов, сливаемых за один проход: --batch-size или MaxOpenFiles
+func batchSize(config *settings) int {
+	if config.BatchSize > 0 {
+		return config.BatchSize
+	}
+	return MaxOpenFiles
+}
+
+// createSortedChunks читает вход и сохраняет отсортированные части во временные файлы.
+// Если весь вход поместился в один чанк, он возвращается в last без записи на диск
+func createSortedChunks(ctx context.Context, source recordSource, config *settings, temps *tempFiles) (*sortedInput, error) {
+	maxChunkSize := chunkSize(config)
+	var chunkFiles []string
+	var currentChunk []string
+	var currentSize int64
+	var header []string
+
+	flushChunk := func() error {
+		if len(currentChunk) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Сортируем чанк
+		sorted := sortRecords(currentChunk, config)
+
+		// Сохраняем во временный файл
+		chunkFile, err := saveChunkToFile(temps, sorted, config)
+		if err != nil {
+			return err
+		}
+
+		chunkFiles = append(chunkFiles, chunkFile)
+		currentChunk = nil
+		currentSize = 0
+
+		return nil
+	}
+
+	count := 0
+	terminated, err := source(func(line string) error {
+		if count++; count%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if len(header) < config.Header {
+			header = append(header, line)
+			return nil
+		}
+		lineSize := estimateMemoryUsage(line)
+
+		if currentSize+lineSize > maxChunkSize && len(currentChunk) > 0 {
+			if err := flushChunk(); err != nil {
+				return err
+			}
+		}
+
+		currentChunk = append(currentChunk, line)
+		currentSize += lineSize
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunkFiles) == 0 {
+		return &sortedInput{last: currentChunk, header: header, terminated: terminated}, nil
+	}
+
+	// Флашим последний чанк
+	if err := flushChunk(); err != nil {
+		return nil, err
+	}
+
+	return &sortedInput{files: chunkFiles, header: header, terminated: terminated}, nil
+}
+
+// saveChunkToFile записывает отсортированный чанк во временный файл
+func saveChunkToFile(temps *tempFiles, records []Record, config *settings) (string, error) {
+	return writeRun(temps, config, func(writer *writeoutput.RecordWriter) error {
+		return newRunWriter(writer, config).addAll(records)
+	})
+}
+
+// writeRun создает временный файл и заполняет его записями через fill,
+// при --compress-program сжимая содержимое. Последняя запись всегда завершается разделителем
+func writeRun(temps *tempFiles, config *settings, fill func(writer *writeoutput.RecordWriter) error) (string, error) {
+	file, err := temps.create()
+	if err != nil {
+		return "", err
+	}
+
+	compressor, err := newCompressWriter(file, config.Compress)
+	if err != nil {
+		file.Close()
+		return "", err
+	}
+
+	writer := writeoutput.NewRecordWriter(compressor, config.Terminator())
+	if err := fill(writer); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := writer.Finish(true); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := compressor.Close(); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	return file.Name(), file.Close()
+}
+
+// mergeFiles сливает уже отсортированные файлы (-m) без повторной сортировки.
+// В памяти одновременно находится по одной строке из каждого файла. Операнд "-"
+// и пустой список файлов означают stdin
+func mergeFiles(ctx context.Context, files []string, config *settings, outputWriter io.Writer) error {
+	writer := writeoutput.NewRecordWriter(outputWriter, config.Terminator())
+	setDebugMarks(writer, config)
+	groups := newGroupWriter(writer, config)
+
+	if len(files) == 0 {
+		files = []string{readinput.Stdin}
+	}
+
+	// Входные файлы не сжаты; промежуточные, если они понадобятся, сжимаются по --compress-program
+	temps := newTempFiles(config.TempDirs)
+	defer temps.removeAll()
+
+	runs, inputTerminated, err := reduceRuns(ctx, files, "", config, temps)
+	if err != nil {
+		return err
+	}
+	compress := ""
+	if len(files) > batchSize(config) {
+		compress = config.Compress
+	}
+
+	// Промежуточные файлы всегда завершаются разделителем, поэтому признак
+	// последней записи входа дает либо первый проход, либо итоговое слияние
+	terminated, err := mergeChunks(ctx, runs, compress, config, groups)
+	if err != nil {
+		return err
+	}
+	if err := groups.flush(); err != nil {
+		return err
+	}
+	return writer.Finish(inputTerminated && terminated)
+}
+
+// reduceRuns выполняет промежуточные проходы слияния, пока файлов больше --batch-size.
+// Соседние группы файлов сливаются в новые временные файлы на месте группы, поэтому
+// порядок файлов и устойчивость слияния сохраняются. compress описывает сжатие исходных
+// файлов, промежуточные сжимаются по --compress-program. Второе значение сообщает,
+// завершалась ли разделителем последняя запись исходных файлов, если проход состоялся
+func reduceRuns(ctx context.Context, runs []string, compress string, config *settings, temps *tempFiles) ([]string, bool, error) {
+	batch := batchSize(config)
+	terminated := true
+	firstPass := true
+
+	for len(runs) > batch {
+		next := make([]string, 0, (len(runs)+batch-1)/batch)
+		for start := 0; start < len(runs); start += batch {
+			group := runs[start:min(start+batch, len(runs))]
+
+			chunks, err := openChunks(group, compress, config)
+			if err != nil {
+				return nil, false, err
+			}
+			name, err := writeRun(temps, config, func(writer *writeoutput.RecordWriter) error {
+				return mergeSorted(ctx, chunks, config, newRunWriter(writer, config))
+			})
+			closeChunks(chunks)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if firstPass {
+				terminated = lastTerminated(chunks, terminated)
+			}
+			// Слитые временные файлы больше не нужны; файлы пользователя не трогаем
+			for _, run := range group {
+				temps.remove(run)
+			}
+			next = append(next, name)
+		}
+
+		runs = next
+		compress = config.Compress
+		firstPass = false
+	}
+
+	return runs, terminated, nil
+}
+
+// mergeChunks открывает отсортированные файлы, сжатые методом compress, и сливает их.
+// Возвращает true, если последняя запись последнего непустого файла завершалась разделителем
+func mergeChunks(ctx context.Context, chunkFiles []string, compress string, config *settings, groups *groupWriter) (bool, error) {
+	chunks, err := openChunks(chunkFiles, compress, config)
+	if err != nil {
+		return false, err
+	}
+	defer closeChunks(chunks)
+
+	if err := mergeSorted(ctx, chunks, config, groups); err != nil {
+		return false, err
+	}
+	return lastTerminated(chunks, true), nil
+}
+
+// openChunks открывает файлы для слияния, при ошибке закрывая уже открытые
+func openChunks(names []string, compress string, config *settings) ([]*Chunk, error) {
+	chunks := make([]*Chunk, 0, len(names))
+	for i, name := range names {
+		file, err := readinput.Open(name)
+		if err != nil {
+			closeChunks(chunks)
+			return nil, err
+		}
+		source, err := newDecompressReader(bufio.NewReader(file), compress)
+		if err != nil {
+			file.Close()
+			closeChunks(chunks)
+			return nil, err
+		}
+		reader := readinput.NewRecordReader(source, config.Terminator(), config.CSVComma())
+		chunks = append(chunks, &Chunk{file: file, source: source, reader: reader, index: i})
+	}
+	return chunks, nil
+}
+
+// closeChunks закрывает все чанки
+func closeChunks(chunks []*Chunk) {
+	for _, chunk := range chunks {
+		chunk.close()
+	}
+}
+
+// lastTerminated обновляет признак terminated по последнему непустому чанку:
+// завершалась ли его последняя запись разделителем
+func lastTerminated(chunks []*Chunk, terminated bool) bool {
+	for _, chunk := range chunks {
+		if chunk.hasRecords {
+			terminated = chunk.terminated
+		}
+	}
+	return terminated
+}
+
+// mergeSorted выполняет k-путевое слияние отсортированных чанков через кучу,
+// периодически проверяя отмену ctx. Записи передаются в groups в порядке слияния
+func mergeSorted(ctx context.Context, chunks []*Chunk, config *settings, groups *groupWriter) error {
+	h := &ChunkHeap{config: config}
+
+	for _, chunk := range chunks {
+		ok, err := chunk.next(config)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.chunks = append(h.chunks, chunk)
+		}
+	}
+	heap.Init(h)
+
+	for count := 1; h.Len() > 0; count++ {
+		if count%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		chunk := h.chunks[0]
+
+		if err := groups.add(&chunk.record); err != nil {
+			return err
+		}
+
+		ok, err := chunk.next(config)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+
+	return nil
+}
+
+// close закрывает распаковщик и файл чанка
+func (c *Chunk) close() {
+	if c.source != nil {
+		c.source.Close()
+	}
+	c.file.Close()
+}
+
+// next читает следующую запись чанка и разбирает ее ключи,
+// возвращает false по достижении конца файла
+func (c *Chunk) next(config *settings) (bool, error) {
+	line, terminated, err := c.reader.Next()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	c.hasRecords = true
+	c.terminated = terminated
+	c.record = newRecord(line, config)
+	return true, nil
+}
+
+// writeLines записывает строки через писатель записей
+func writeLines(writer *writeoutput.RecordWriter, lines []string) error {
+	for _, line := range lines {
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package sorting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	parsingflags "main/parsingFlags"
+	readinput "main/readInput"
+	writeoutput "main/writeOutput"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// compressMethods — варианты хранения временных чанков: без сжатия, gzip и flate
+var compressMethods = []string{"", CompressGzip, CompressFlate}
+
+// writeLogInput создает файл с повторяющимися строками журнала, которые
+// хорошо сжимаются, и возвращает его путь и размер
+func writeLogInput(tb testing.TB, n int) (string, int64) {
+	rng := rand.New(rand.NewSource(1))
+	levels := []string{"INFO", "WARN", "ERROR", "DEBUG"}
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "2024-05-%02d 12:%02d:%02d %s service=api request_id=%08d status=%d\n",
+			rng.Intn(28)+1, rng.Intn(60), rng.Intn(60), levels[rng.Intn(len(levels))], rng.Intn(n), 200+rng.Intn(4)*100)
+	}
+
+	path := filepath.Join(tb.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path, int64(sb.Len())
+}
+
+func methodName(method string) string {
+	if method == "" {
+		return "none"
+	}
+	return method
+}
+
+// TestCompressedChunks проверяет, что сжатие временных файлов не меняет результат
+func TestCompressedChunks(t *testing.T) {
+	input, _ := writeLogInput(t, 50_000)
+
+	var want []byte
+	for _, method := range compressMethods {
+		config := &parsingflags.Config{Parallel: 1, BufferSize: minChunkSize, TempDirs: []string{t.TempDir()}, Compress: method}
+		var out bytes.Buffer
+		if err := New(WithConfig(config)).SortFiles(context.Background(), []string{input}, &out); err != nil {
+			t.Fatalf("%s: %v", methodName(method), err)
+		}
+		if want == nil {
+			want = out.Bytes()
+		} else if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("%s: output differs from uncompressed sort", methodName(method))
+		}
+	}
+}
+
+// TestMultiPassMerge проверяет, что промежуточные проходы с малым --batch-size
+// дают тот же результат, что и слияние всех чанков за один проход
+func TestMultiPassMerge(t *testing.T) {
+	input, _ := writeLogInput(t, 50_000)
+
+	var want []byte
+	for _, batch := range []int{MaxOpenFiles, 3, 2} {
+		dir := t.TempDir()
+		config := &parsingflags.Config{Parallel: 1, BufferSize: minChunkSize, TempDirs: []string{dir}, BatchSize: batch, Compress: CompressGzip}
+		var out bytes.Buffer
+		if err := New(WithConfig(config)).SortFiles(context.Background(), []string{input}, &out); err != nil {
+			t.Fatalf("batch %d: %v", batch, err)
+		}
+		if want == nil {
+			want = out.Bytes()
+		} else if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("batch %d: output differs from single-pass merge", batch)
+		}
+
+		if left, _ := os.ReadDir(dir); len(left) != 0 {
+			t.Errorf("batch %d: %d temporary files left", batch, len(left))
+		}
+	}
+}
+
+// BenchmarkCompressedChunks сравнивает объем временных файлов на диске
+// (disk-bytes/op) и скорость внешней сортировки для каждого метода сжатия
+func BenchmarkCompressedChunks(b *testing.B) {
+	input, size := writeLogInput(b, 500_000)
+
+	for _, method := range compressMethods {
+		b.Run(methodName(method), func(b *testing.B) {
+			config := plain(&parsingflags.Config{Parallel: 1, BufferSize: 4 * minChunkSize, TempDirs: []string{b.TempDir()}, Compress: method})
+			b.SetBytes(size)
+
+			var diskBytes int64
+			for i := 0; i < b.N; i++ {
+				temps := newTempFiles(config.TempDirs)
+				chunked, err := createSortedChunks(context.Background(), func(fn func(line string) error) (bool, error) {
+					return readinput.ForEachLine([]string{input}, config.Terminator(), 0, fn)
+				}, config, temps)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, name := range chunked.files {
+					info, err := os.Stat(name)
+					if err != nil {
+						b.Fatal(err)
+					}
+					diskBytes += info.Size()
+				}
+
+				writer := writeoutput.NewRecordWriter(io.Discard, config.Terminator())
+				if _, err := mergeChunks(context.Background(), chunked.files, config.Compress, config, newGroupWriter(writer, config)); err != nil {
+					b.Fatal(err)
+				}
+				temps.removeAll()
+			}
+			b.ReportMetric(float64(diskBytes)/float64(b.N), "disk-bytes/op")
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package sorting
+
+import (
+	"fmt"
+	writeoutput "main/writeOutput"
+)
+
+// groupWriter выводит отсортированные записи, объединяя равные по ключам в группы:
+// с -u выводится первая запись группы, с --repeated — только группы из нескольких
+// записей, с --count — первая запись с числом записей группы, как у uniq -c
+type groupWriter struct {
+	writer   *writeoutput.RecordWriter
+	config   *settings
+	grouped  bool // объединять ли равные записи
+	counting bool // нужен ли размер группы для --repeated и --count
+
+	first Record
+	count int
+}
+
+// newGroupWriter создает писатель групп для итогового вывода
+func newGroupWriter(writer *writeoutput.RecordWriter, config *settings) *groupWriter {
+	return &groupWriter{
+		writer:   writer,
+		config:   config,
+		grouped:  config.Grouped(),
+		counting: config.Repeated || config.Count,
+	}
+}
+
+// newRunWriter создает писатель для временных файлов: дубликаты -u можно убрать
+// уже в них, а группы --repeated и --count считаются только по всему входу
+func newRunWriter(writer *writeoutput.RecordWriter, config *settings) *groupWriter {
+	return &groupWriter{
+		writer:  writer,
+		config:  config,
+		grouped: config.Unique && !config.Repeated && !config.Count,
+	}
+}
+
+// add добавляет очередную запись в порядке сортировки
+func (g *groupWriter) add(record *Record) error {
+	if !g.grouped {
+		return g.writer.Write(record.Line)
+	}
+	if g.count > 0 && compareRecords(&g.first, record, g.config) == 0 {
+		g.count++
+		return nil
+	}
+
+	if err := g.flush(); err != nil {
+		return err
+	}
+	g.first, g.count = *record, 1
+	// Без подсчета первую запись группы можно вывести сразу
+	if !g.counting {
+		return g.writer.Write(record.Line)
+	}
+	return nil
+}
+
+// addAll добавляет отсортированные записи
+func (g *groupWriter) addAll(records []Record) error {
+	for i := range records {
+		if err := g.add(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush выводит накопленную группу --repeated или --count; вызывается и после последней записи
+func (g *groupWriter) flush() error {
+	if !g.counting || g.count == 0 || (g.config.Repeated && g.count < 2) {
+		return nil
+	}
+
+	line := g.first.Line
+	if g.config.Count {
+		line = fmt.Sprintf("%7d %s", g.count, line)
+	}
+	g.count = 0
+	return g.writer.Write(line)
+}
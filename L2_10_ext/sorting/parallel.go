@@ -0,0 +1,79 @@
+package sorting
+
+import (
+	"sync"
+)
+
+// minParallelLines — срезы меньше этого размера быстрее отсортировать в одной горутине
+const minParallelLines = 1 << 14
+
+// sortParallel делит строки на config.Parallel частей, одновременно строит для них записи
+// и сортирует, затем попарно сливает части. Результат совпадает с последовательной сортировкой
+func sortParallel(lines []string, config *settings) []Record {
+	parts := min(config.Parallel, len(lines))
+	records := make([]Record, len(lines))
+
+	// bounds хранит границы отсортированных частей: часть k — lines[bounds[k]:bounds[k+1]]
+	bounds := make([]int, parts+1)
+	for k := range bounds {
+		bounds[k] = k * len(lines) / parts
+	}
+
+	var wg sync.WaitGroup
+	for k := 0; k < parts; k++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				records[i] = newRecord(lines[i], config)
+			}
+			sortPart(records[lo:hi], config)
+		}(bounds[k], bounds[k+1])
+	}
+	wg.Wait()
+
+	src, dst := records, make([]Record, len(records))
+	for len(bounds) > 2 {
+		next := []int{0}
+		for k := 0; k+1 < len(bounds); k += 2 {
+			if k+2 >= len(bounds) {
+				// Непарная последняя часть переносится без слияния
+				copy(dst[bounds[k]:], src[bounds[k]:bounds[k+1]])
+				next = append(next, bounds[k+1])
+				continue
+			}
+
+			lo, mid, hi := bounds[k], bounds[k+1], bounds[k+2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeRuns(dst[lo:hi], src[lo:mid], src[mid:hi], config)
+			}()
+			next = append(next, hi)
+		}
+		wg.Wait()
+
+		src, dst = dst, src
+		bounds = next
+	}
+
+	return src
+}
+
+// mergeRuns сливает два отсортированных среза записей в dst. При равенстве первой идет
+// запись из левого среза, поэтому слияние сохраняет стабильность
+func mergeRuns(dst, left, right []Record, config *settings) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if compareRecords(&right[j], &left[i], config) < 0 {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
@@ -0,0 +1,61 @@
+package sorting
+
+import (
+	parsingflags "main/parsingFlags"
+	"slices"
+	"testing"
+)
+
+// TestParallelMatchesSerial проверяет, что параллельная сортировка дает тот же
+// результат байт в байт, что и последовательная, в том числе для равных ключей
+func TestParallelMatchesSerial(t *testing.T) {
+	lines := generateLines(minParallelLines*3 + 7)
+
+	key := func(field int, ordering parsingflags.Ordering) parsingflags.KeySpec {
+		return parsingflags.KeySpec{StartField: field, StartChar: 1, EndField: field, Ordering: ordering}
+	}
+	month := key(3, parsingflags.Ordering{Month: true})
+
+	tests := []struct {
+		name   string
+		config parsingflags.Config
+	}{
+		{"text", parsingflags.Config{}},
+		{"reverse", parsingflags.Config{Ordering: parsingflags.Ordering{Reverse: true}}},
+		{"stable key", parsingflags.Config{Stable: true, Keys: []parsingflags.KeySpec{month}}},
+		{"stable reverse key", parsingflags.Config{Stable: true, Keys: []parsingflags.KeySpec{
+			key(3, parsingflags.Ordering{Month: true, Reverse: true}),
+		}}},
+		{"unique key", parsingflags.Config{Unique: true, Keys: []parsingflags.KeySpec{month}}},
+		{"unique numeric", parsingflags.Config{Unique: true, Keys: []parsingflags.KeySpec{
+			key(2, parsingflags.Ordering{HumanNumeric: true}),
+		}}},
+		{"multiple keys", parsingflags.Config{Keys: []parsingflags.KeySpec{
+			month,
+			key(2, parsingflags.Ordering{HumanNumeric: true, Reverse: true}),
+		}}},
+		{"multiple keys stable", parsingflags.Config{Stable: true, Keys: []parsingflags.KeySpec{
+			key(2, parsingflags.Ordering{Numeric: true}),
+			month,
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serial, parallel := tt.config, tt.config
+			serial.Delimiter, parallel.Delimiter = "\t", "\t"
+			serial.Parallel, parallel.Parallel = 1, 8
+
+			want := SortLines(lines, &serial)
+			got := SortLines(lines, &parallel)
+			if !slices.Equal(got, want) {
+				for i := range min(len(got), len(want)) {
+					if got[i] != want[i] {
+						t.Fatalf("Parallel: 8 differs from Parallel: 1 at line %d: %q, want %q", i, got[i], want[i])
+					}
+				}
+				t.Fatalf("Parallel: 8 returned %d lines, want %d", len(got), len(want))
+			}
+		})
+	}
+}
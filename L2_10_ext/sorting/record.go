@@ -0,0 +1,178 @@
+package sorting
+
+import (
+	"cmp"
+	"encoding/binary"
+	"hash/fnv"
+	parsingflags "main/parsingFlags"
+	"slices"
+	"strings"
+)
+
+// sortKey хранит значение одного ключа строки, разобранное заранее
+type sortKey struct {
+	text   string  // подстрока ключа для побайтового сравнения
+	runes  []rune  // ключ после -f, -d, -i и --collate
+	number number  // точное значение для -n и -h
+	num    float64 // значение для -g и -M
+	class  int     // класс значения для -g или порядок суффикса для -h
+	hash   uint64  // хеш ключа с солью для -R
+	raw    []byte  // ключ, выделенный KeyExtractor из WithKey
+}
+
+// Record — строка вместе с ключами, которые извлекаются и разбираются один раз,
+// а не при каждом из n·log n сравнений
+type Record struct {
+	Line string
+	keys []sortKey
+}
+
+// NewRecord извлекает и разбирает ключи строки согласно конфигу
+func NewRecord(line string, config *parsingflags.Config) Record {
+	return newRecord(line, plain(config))
+}
+
+// newRecord извлекает и разбирает ключи строки. Пользовательские ключи
+// Sorter следуют за ключами -k
+func newRecord(line string, config *settings) Record {
+	if len(config.Keys) == 0 && len(config.customKeys) == 0 {
+		key := line
+		if config.IgnoreBlanks {
+			key = line[skipBlanks(line, 0):]
+		}
+		return Record{Line: line, keys: []sortKey{parseKey(key, config.Ordering, config)}}
+	}
+
+	keys := make([]sortKey, len(config.Keys), len(config.Keys)+len(config.customKeys))
+	if len(config.Keys) > 0 {
+		text, fields := recordFields(line, config)
+		for i, key := range config.Keys {
+			keys[i] = parseKey(extractKey(text, fields, key), key.Ordering, config)
+		}
+	}
+	for _, key := range config.customKeys {
+		keys = append(keys, sortKey{raw: key.extract([]byte(line))})
+	}
+	return Record{Line: line, keys: keys}
+}
+
+// makeRecords строит записи для всех строк
+func makeRecords(lines []string, config *settings) []Record {
+	records := make([]Record, len(lines))
+	for i, line := range lines {
+		records[i] = newRecord(line, config)
+	}
+	return records
+}
+
+// parseKey разбирает значение ключа в соответствии со способом сравнения
+func parseKey(text string, ordering parsingflags.Ordering, config *settings) sortKey {
+	key := sortKey{text: text}
+
+	switch {
+	case ordering.Numeric:
+		// Как в GNU sort, нечисловое значение считается нулем
+		key.number = parseNumber(text)
+	case ordering.GeneralNumeric:
+		key.num, key.class = parseGeneralNumeric(text)
+	case ordering.Random:
+		// Равные ключи получают равный хеш, поэтому остаются рядом
+		hashed := text
+		if needsRunes(ordering) {
+			hashed = string(filterRunes(text, ordering))
+		}
+		key.hash = randomHash(hashed, config.RandomSeed)
+	case ordering.HumanNumeric:
+		key.class, key.number = humanOrder(text), parseNumber(text)
+	case ordering.Month:
+		key.num = float64(MonthToNumber(text))
+	case needsRunes(ordering):
+		key.runes = filterRunes(text, ordering)
+	}
+
+	return key
+}
+
+// CompareRecords сравнивает записи по ключам в порядке их объявления и возвращает -1, 0 или 1.
+// Если все ключи равны, строки сравниваются побайтово целиком, как в GNU sort. Это сравнение
+// отключают -s и, чтобы равенство определяли только ключи, -u, --repeated и --count
+func CompareRecords(a, b *Record, config *parsingflags.Config) int {
+	return compareRecords(a, b, plain(config))
+}
+
+// compareRecords сравнивает записи как CompareRecords, учитывая пользовательские ключи
+func compareRecords(a, b *Record, config *settings) int {
+	for i := range a.keys {
+		// Пользовательский ключ сравнивается своей функцией, глобальный -r его обращает
+		if custom := i - len(config.Keys); custom >= 0 && len(config.customKeys) > 0 {
+			result := config.customKeys[custom].compare(a.keys[i].raw, b.keys[i].raw)
+			if config.Reverse {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+			continue
+		}
+
+		ordering := config.Ordering
+		if len(config.Keys) > 0 {
+			ordering = config.Keys[i].Ordering
+		}
+
+		if result := compareKeys(&a.keys[i], &b.keys[i], ordering); result != 0 {
+			return result
+		}
+	}
+
+	if config.Stable || config.Grouped() {
+		return 0
+	}
+
+	result := strings.Compare(a.Line, b.Line)
+	if config.Reverse {
+		return -result
+	}
+	return result
+}
+
+// compareKeys сравнивает разобранные значения ключей с учетом модификаторов
+func compareKeys(a, b *sortKey, ordering parsingflags.Ordering) int {
+	var result int
+
+	switch {
+	case ordering.Numeric:
+		result = compareNumbers(a.number, b.number)
+	case ordering.Month:
+		result = cmp.Compare(a.num, b.num)
+	case ordering.GeneralNumeric:
+		result = cmp.Or(cmp.Compare(a.class, b.class), cmp.Compare(a.num, b.num))
+	case ordering.HumanNumeric:
+		result = cmp.Or(cmp.Compare(a.class, b.class), compareNumbers(a.number, b.number))
+	case ordering.Version:
+		result = compareVersions(a.text, b.text)
+	case ordering.Random:
+		result = cmp.Compare(a.hash, b.hash)
+	case ordering.Collate:
+		result = collate(a.runes, b.runes)
+	case needsRunes(ordering):
+		result = slices.Compare(a.runes, b.runes)
+	default:
+		result = strings.Compare(a.text, b.text)
+	}
+
+	if ordering.Reverse {
+		return -result
+	}
+	return result
+}
+
+// randomHash возвращает хеш FNV-1a ключа с солью seed
+func randomHash(text string, seed uint64) uint64 {
+	h := fnv.New64a()
+	var salt [8]byte
+	binary.LittleEndian.PutUint64(salt[:], seed)
+	h.Write(salt[:])
+	h.Write([]byte(text))
+	return h.Sum64()
+}
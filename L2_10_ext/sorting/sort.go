@@ -1,173 +1,291 @@
 package sorting
 
 import (
-	"fmt"
+	"cmp"
+	"encoding/csv"
+	"errors"
 	parsingflags "main/parsingFlags"
-	"os"
+	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
-	"unicode"
 )
 
-func InternalSort(lines []string, config *parsingflags.Config) {
-	if len(lines) == 0 {
-		return
+// months — таблица месяцев, общая для всех сравнений
+var months = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4,
+	"may": 5, "jun": 6, "jul": 7, "aug": 8,
+	"sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// unitOrders — порядок суффиксов человеко-читаемых чисел. Как в GNU sort, важен только
+// порядок суффикса, поэтому 1K и 1Ki (du -h) равны, а 1023 меньше 1K
+var unitOrders = map[byte]int{
+	'K': 1, 'k': 1,
+	'M': 2, 'G': 3, 'T': 4, 'P': 5,
+	'E': 6, 'Z': 7, 'Y': 8, 'R': 9, 'Q': 10,
+}
+
+// MonthToNumber возвращает номер месяца по его названию, 0 для неизвестных
+func MonthToNumber(month string) int {
+	// Как в GNU sort, ведущие пробелы пропускаются и учитываются первые три буквы
+	month = strings.TrimLeft(month, " \t")
+	if len(month) > 3 {
+		month = month[:3]
 	}
+	return months[strings.ToLower(month)]
+}
 
-	if config.CheckSorted {
-		if IsSorted(lines, config) {
-			os.Exit(0)
-		} else {
-			fmt.Fprintf(os.Stderr, "sort: disorder in input\n")
-			os.Exit(1)
-		}
+// ParseHumanNumber разбирает человеко-читаемое число (1K, 2.5Mi, -3G) и возвращает
+// порядок суффикса со знаком числа и само число без суффикса. Нечисловое значение
+// и ноль с любым суффиксом дают нулевой порядок и сортируются как 0
+func ParseHumanNumber(s string) (int, float64) {
+	num, _ := parseNumericValueForSort(s)
+	return humanOrder(s), num
+}
+
+// humanOrder возвращает порядок суффикса человеко-читаемого числа со знаком числа
+func humanOrder(s string) int {
+	s = strings.TrimLeft(s, " \t")
+
+	pos := 0
+	negative := pos < len(s) && s[pos] == '-'
+	if negative {
+		pos++
 	}
 
-	sorted := SortLines(lines, config)
+	nonzero := false
+	for pos < len(s) && isDigit(s[pos]) {
+		nonzero = nonzero || s[pos] != '0'
+		pos++
+	}
+	if pos < len(s) && s[pos] == '.' {
+		pos++
+		for pos < len(s) && isDigit(s[pos]) {
+			nonzero = nonzero || s[pos] != '0'
+			pos++
+		}
+	}
 
-	for _, line := range sorted {
-		fmt.Println(line)
+	order := 0
+	if nonzero && pos < len(s) {
+		order = unitOrders[s[pos]]
+	}
+	if negative {
+		order = -order
 	}
+	return order
 }
 
-// MonthToNumber создает таблицу месяцев
-func MonthToNumber(month string) int {
-	months := map[string]int{
-		"jan": 1, "feb": 2, "mar": 3, "apr": 4,
-		"may": 5, "jun": 6, "jul": 7, "aug": 8,
-		"sep": 9, "oct": 10, "nov": 11, "dec": 12,
-	}
-	return months[strings.ToLower(month)]
+// GetSortKey возвращает часть строки, выделенную ключом key
+func GetSortKey(line string, key parsingflags.KeySpec, config *parsingflags.Config) string {
+	text, fields := recordFields(line, plain(config))
+	return extractKey(text, fields, key)
 }
 
-// ParseHumanNumber парсит человеко-читаемые числа (1K, 2M, 3G)
-func ParseHumanNumber(s string) float64 {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0
+// recordFields возвращает текст, в котором выделяются ключи, и границы его полей.
+// С --csv поля разбирает encoding/csv: кавычки снимаются, а значения соединяются
+// разделителем, поэтому разделитель внутри кавычек не делит поле
+func recordFields(line string, config *settings) (string, [][2]int) {
+	comma := config.CSVComma()
+	if comma == 0 {
+		return line, fieldBounds(line, config.Delimiter)
 	}
 
-	s = strings.ReplaceAll(s, " ", "")
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	values, err := reader.Read()
+	if err != nil {
+		return line, fieldBounds(line, string(comma))
+	}
 
-	multipliers := map[string]float64{
-		"K": 1e3, "k": 1e3,
-		"M": 1e6, "m": 1e6,
-		"G": 1e9, "g": 1e9,
-		"T": 1e12, "t": 1e12,
+	var text strings.Builder
+	fields := make([][2]int, len(values))
+	for i, value := range values {
+		if i > 0 {
+			text.WriteRune(comma)
+		}
+		fields[i][0] = text.Len()
+		text.WriteString(value)
+		fields[i][1] = text.Len()
 	}
+	return text.String(), fields
+}
 
-	for suffix, multiplier := range multipliers {
-		if strings.HasSuffix(s, suffix) {
-			numStr := s[:len(s)-len(suffix)]
-			num, err := strconv.ParseFloat(numStr, 64)
-			if err != nil {
-				return 0
+// extractKey выделяет ключ key из строки с уже найденными границами полей
+func extractKey(line string, fields [][2]int, key parsingflags.KeySpec) string {
+	start, end := keySpan(line, fields, key)
+	return line[start:end]
+}
+
+// keySpan возвращает границы [начало, конец) ключа key в строке с найденными границами полей
+func keySpan(line string, fields [][2]int, key parsingflags.KeySpec) (int, int) {
+	start := len(line)
+	if key.StartField <= len(fields) {
+		start = fields[key.StartField-1][0]
+		if key.IgnoreBlanks {
+			start = skipBlanks(line, start)
+		}
+		start = min(start+key.StartChar-1, len(line))
+	}
+
+	end := len(line)
+	if key.EndField > 0 && key.EndField <= len(fields) {
+		field := fields[key.EndField-1]
+		end = field[1]
+		if key.EndChar > 0 {
+			pos := field[0]
+			if key.IgnoreBlanks {
+				pos = skipBlanks(line, pos)
 			}
-			return num * multiplier
+			end = min(pos+key.EndChar, end)
 		}
 	}
 
-	num, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0
+	if end <= start {
+		return start, start
 	}
-	return num
+	return start, end
 }
 
-// GetSortKey создает подстроку с нужным столбцом
-func GetSortKey(line string, config *parsingflags.Config) string {
-	if config.KeyColumn == 0 {
-		if config.IgnoreBlanks {
-			return strings.TrimRightFunc(line, unicode.IsSpace)
+// fieldBounds возвращает границы [начало, конец) каждого поля строки.
+// Без разделителя поле, как в GNU sort, состоит из ведущих пробелов и следующего за ними слова
+func fieldBounds(line, delimiter string) [][2]int {
+	var fields [][2]int
+	start := 0
+
+	if delimiter == "" {
+		for start < len(line) {
+			end := skipBlanks(line, start)
+			for end < len(line) && !isBlank(line[end]) {
+				end++
+			}
+			fields = append(fields, [2]int{start, end})
+			start = end
 		}
-		return line
+		return fields
 	}
 
-	columns := strings.Split(line, config.Delimiter)
-	colIndex := config.KeyColumn - 1
-
-	if colIndex < 0 || colIndex >= len(columns) {
-		return ""
+	for {
+		idx := strings.Index(line[start:], delimiter)
+		if idx < 0 {
+			return append(fields, [2]int{start, len(line)})
+		}
+		fields = append(fields, [2]int{start, start + idx})
+		start += idx + len(delimiter)
 	}
+}
 
-	value := columns[colIndex]
-	if config.IgnoreBlanks {
-		value = strings.TrimRightFunc(value, unicode.IsSpace)
+// skipBlanks возвращает позицию первого непробельного символа начиная с pos
+func skipBlanks(line string, pos int) int {
+	for pos < len(line) && isBlank(line[pos]) {
+		pos++
 	}
+	return pos
+}
 
-	return value
+// isBlank сообщает, является ли байт пробелом или табуляцией
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
 }
 
 // IsSorted проверяет, отсортирован ли массив строк
 func IsSorted(lines []string, config *parsingflags.Config) bool {
 	for i := 1; i < len(lines); i++ {
-		if !CompareStrings(lines[i-1], lines[i], config) {
+		if Compare(lines[i-1], lines[i], config) > 0 {
 			return false
 		}
 	}
 	return true
 }
 
-// RemoveDuplicates удаляет повторяющиеся строки
-func RemoveDuplicates(lines []string) []string {
-	if len(lines) == 0 {
-		return lines
-	}
+// RemoveDuplicates оставляет из каждой группы записей с равными ключами первую.
+// Записи должны быть отсортированы; как в GNU sort, равенство определяет компаратор,
+// поэтому с -n строки 1 и 01 считаются одинаковыми
+func RemoveDuplicates(records []Record, config *parsingflags.Config) []Record {
+	return removeDuplicates(records, plain(config))
+}
 
-	result := []string{lines[0]}
-	for i := 1; i < len(lines); i++ {
-		if lines[i] != lines[i-1] {
-			result = append(result, lines[i])
-		}
-	}
-	return result
+// removeDuplicates удаляет дубликаты как RemoveDuplicates, учитывая пользовательские ключи
+func removeDuplicates(records []Record, config *settings) []Record {
+	return slices.CompactFunc(records, func(a, b Record) bool {
+		return compareRecords(&a, &b, config) == 0
+	})
 }
 
 // SortLines сортирует массив строк
 func SortLines(lines []string, config *parsingflags.Config) []string {
-	sorted := make([]string, len(lines))
-	copy(sorted, lines)
-
-	sort.Slice(sorted, func(i, j int) bool {
-		return CompareStrings(sorted[i], sorted[j], config)
-	})
+	return sortLines(lines, plain(config))
+}
 
+// sortLines сортирует массив строк с учетом пользовательских ключей
+func sortLines(lines []string, config *settings) []string {
+	records := sortRecords(lines, config)
 	if config.Unique {
-		sorted = RemoveDuplicates(sorted)
+		records = removeDuplicates(records, config)
 	}
 
+	sorted := make([]string, len(records))
+	for i := range records {
+		sorted[i] = records[i].Line
+	}
 	return sorted
 }
 
+// sortRecords строит записи для строк и сортирует их, без удаления дубликатов
+func sortRecords(lines []string, config *settings) []Record {
+	if config.Parallel > 1 && len(lines) >= minParallelLines {
+		return sortParallel(lines, config)
+	}
+	records := makeRecords(lines, config)
+	sortPart(records, config)
+	return records
+}
+
+// sortPart сортирует записи на месте в одной горутине
+func sortPart(records []Record, config *settings) {
+	less := func(i, j int) bool {
+		return compareRecords(&records[i], &records[j], config) < 0
+	}
+
+	// Со стабильной сортировкой и при объединении равных записей (-u, --repeated, --count)
+	// строки с равными ключами сохраняют порядок ввода, иначе порядок однозначно задает
+	// побайтовое сравнение строк целиком
+	if config.Stable || config.Grouped() {
+		sort.SliceStable(records, less)
+	} else {
+		sort.Slice(records, less)
+	}
+}
+
 // parseNumericValueForSort парсит число из подстроки
 func parseNumericValueForSort(s string) (float64, bool) {
 	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, false
-	}
 
-	clean := make([]rune, 0, len(s))
+	end := 0
+	if end < len(s) && s[end] == '-' {
+		end++
+	}
 	hasPoint := false
-
-	for i, r := range s {
-		if unicode.IsDigit(r) {
-			clean = append(clean, r)
-		} else if r == '.' && !hasPoint {
-			clean = append(clean, r)
+	for end < len(s) {
+		if s[end] >= '0' && s[end] <= '9' {
+			end++
+		} else if s[end] == '.' && !hasPoint {
 			hasPoint = true
-		} else if r == '-' && i == 0 {
-			clean = append(clean, r)
+			end++
 		} else {
 			break
 		}
 	}
 
-	if len(clean) == 0 {
+	if end == 0 {
 		return 0, false
 	}
 
-	num, err := strconv.ParseFloat(string(clean), 64)
+	num, err := strconv.ParseFloat(s[:end], 64)
 	if err != nil {
 		return 0, false
 	}
@@ -175,64 +293,169 @@ func parseNumericValueForSort(s string) (float64, bool) {
 	return num, true
 }
 
-// CompareStrings сравнивает две строки согласно конфигу
-func CompareStrings(a, b string, config *parsingflags.Config) bool {
-	keyA := GetSortKey(a, config)
-	keyB := GetSortKey(b, config)
-	numberIsSame := false
-
-	if config.Numeric {
-		numA, isNumA := parseNumericValueForSort(keyA)
-		numB, isNumB := parseNumericValueForSort(keyB)
-
-		if isNumA && isNumB {
-			if numA != numB {
-				if config.Reverse {
-					return numA > numB
-				}
-				return numA < numB
-			}
-			numberIsSame = true
-		}
-		if isNumA && !isNumB {
-			return true
-		}
-		if !isNumA && isNumB {
-			return false
+// number — точное значение числа для -n и -h: знак, цифры целой части без ведущих
+// нулей и дробной без завершающих. Как strnumcmp в GNU sort, числа сравниваются
+// по цифрам, поэтому не теряют точность, в отличие от float64
+type number struct {
+	negative bool
+	integer  string
+	fraction string
+}
+
+// parseNumber разбирает число в начале s: минус, цифры и дробную часть.
+// Нечисловое значение, как в GNU sort, равно нулю
+func parseNumber(s string) number {
+	s = strings.TrimSpace(s)
+
+	var n number
+	pos := 0
+	if pos < len(s) && s[pos] == '-' {
+		n.negative = true
+		pos++
+	}
+
+	start := pos
+	for pos < len(s) && isDigit(s[pos]) {
+		pos++
+	}
+	n.integer = strings.TrimLeft(s[start:pos], "0")
+
+	if pos < len(s) && s[pos] == '.' {
+		pos++
+		start = pos
+		for pos < len(s) && isDigit(s[pos]) {
+			pos++
 		}
+		n.fraction = strings.TrimRight(s[start:pos], "0")
 	}
 
-	if config.HumanNumeric {
-		numA := ParseHumanNumber(keyA)
-		numB := ParseHumanNumber(keyB)
+	// Минус нуля не меняет значение
+	if n.integer == "" && n.fraction == "" {
+		n.negative = false
+	}
+	return n
+}
 
-		if (numA != 0 || numB != 0) && !(numA == 0 && numB == 0) {
-			if config.Reverse {
-				return numA > numB
-			}
-			return numA < numB
+// compareNumbers сравнивает числа и возвращает -1, 0 или 1: сначала знак, затем
+// длина целой части, затем цифры целой и дробной частей
+func compareNumbers(a, b number) int {
+	if a.negative != b.negative {
+		if a.negative {
+			return -1
 		}
+		return 1
+	}
+
+	result := cmp.Or(
+		cmp.Compare(len(a.integer), len(b.integer)),
+		strings.Compare(a.integer, b.integer),
+		strings.Compare(a.fraction, b.fraction),
+	)
+	if a.negative {
+		return -result
+	}
+	return result
+}
+
+// Классы значений для -g: как в GNU sort, нечисловые значения идут первыми, затем NaN, затем числа
+const (
+	generalNotNumber = iota
+	generalNaN
+	generalNumber
+)
+
+// parseGeneralNumeric разбирает число в начале строки так же, как strtod:
+// знак, дробная часть, экспонента, inf и nan. Возвращает значение и его класс
+func parseGeneralNumeric(s string) (float64, int) {
+	s = strings.TrimLeft(s, " \t")
+
+	end := generalNumberLen(s)
+	if end == 0 {
+		return 0, generalNotNumber
+	}
+
+	text := s[:end]
+	// Шестнадцатеричное целое ParseFloat принимает только с двоичной экспонентой
+	if digits := strings.TrimLeft(text, "+-"); len(digits) > 1 && (digits[1] == 'x' || digits[1] == 'X') {
+		text += "p0"
+	}
+
+	num, err := strconv.ParseFloat(text, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, generalNotNumber
+	}
+	if math.IsNaN(num) {
+		return 0, generalNaN
+	}
+	return num, generalNumber
+}
+
+// generalNumberLen возвращает длину числа в начале s в понимании strtod, 0 — если числа нет
+func generalNumberLen(s string) int {
+	end := 0
+	if end < len(s) && (s[end] == '+' || s[end] == '-') {
+		end++
 	}
 
-	if config.Month {
-		monthA := MonthToNumber(keyA)
-		monthB := MonthToNumber(keyB)
-		if monthA != 0 && monthB != 0 {
-			if config.Reverse {
-				return monthA > monthB
+	rest := strings.ToLower(s[end:])
+	switch {
+	case strings.HasPrefix(rest, "infinity"):
+		end += len("infinity")
+	case strings.HasPrefix(rest, "inf"), strings.HasPrefix(rest, "nan"):
+		end += 3
+	case len(rest) > 2 && strings.HasPrefix(rest, "0x") && isHexDigit(rest[2]):
+		end += 2
+		for end < len(s) && isHexDigit(s[end]) {
+			end++
+		}
+	default:
+		digits := 0
+		for end < len(s) && isDigit(s[end]) {
+			end++
+			digits++
+		}
+		if end < len(s) && s[end] == '.' {
+			end++
+			for end < len(s) && isDigit(s[end]) {
+				end++
+				digits++
 			}
-			return monthA < monthB
 		}
-	}
-	if numberIsSame {
-		if config.Reverse {
-			return a > b
+		if digits == 0 {
+			return 0
 		}
-		return a < b
-	}
 
-	if config.Reverse {
-		return keyA > keyB
+		// Экспонента учитывается, только если за ней есть цифры
+		if end < len(s) && (s[end] == 'e' || s[end] == 'E') {
+			exp := end + 1
+			if exp < len(s) && (s[exp] == '+' || s[exp] == '-') {
+				exp++
+			}
+			if exp < len(s) && isDigit(s[exp]) {
+				for exp < len(s) && isDigit(s[exp]) {
+					exp++
+				}
+				end = exp
+			}
+		}
 	}
-	return keyA < keyB
+	return end
+}
+
+// CompareStrings сравнивает две строки согласно конфигу
+func CompareStrings(a, b string, config *parsingflags.Config) bool {
+	return Compare(a, b, config) < 0
+}
+
+// Compare сравнивает две строки по ключам в порядке их объявления и возвращает -1, 0 или 1.
+// Для сортировки большого числа строк выгоднее один раз построить записи через NewRecord
+func Compare(a, b string, config *parsingflags.Config) int {
+	return compare(a, b, plain(config))
+}
+
+// compare сравнивает две строки как Compare, учитывая пользовательские ключи
+func compare(a, b string, config *settings) int {
+	recordA := newRecord(a, config)
+	recordB := newRecord(b, config)
+	return compareRecords(&recordA, &recordB, config)
 }
@@ -0,0 +1,190 @@
+package sorting
+
+import (
+	"fmt"
+	parsingflags "main/parsingFlags"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// benchmarkLines — объем входа для бенчмарков сортировки
+const benchmarkLines = 1_000_000
+
+// generateLines создает воспроизводимый вход вида "имя\tразмер\tмесяц"
+func generateLines(n int) []string {
+	rng := rand.New(rand.NewSource(1))
+	monthNames := []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	suffixes := []string{"", "K", "M", "G"}
+
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("item%07d\t%d%s\t%s",
+			rng.Intn(n), rng.Intn(1000), suffixes[rng.Intn(len(suffixes))], monthNames[rng.Intn(len(monthNames))])
+	}
+	return lines
+}
+
+// benchmarkConfigs — типичные варианты сортировки, для которых сравнивается скорость
+func benchmarkConfigs() map[string]*parsingflags.Config {
+	key := func(field int, ordering parsingflags.Ordering) []parsingflags.KeySpec {
+		return []parsingflags.KeySpec{{StartField: field, StartChar: 1, EndField: field, Ordering: ordering}}
+	}
+	return map[string]*parsingflags.Config{
+		"text":    {Delimiter: "\t", Parallel: 1},
+		"numeric": {Delimiter: "\t", Parallel: 1, Keys: key(2, parsingflags.Ordering{Numeric: true})},
+		"human":   {Delimiter: "\t", Parallel: 1, Keys: key(2, parsingflags.Ordering{HumanNumeric: true})},
+		"month":   {Delimiter: "\t", Parallel: 1, Keys: key(3, parsingflags.Ordering{Month: true})},
+	}
+}
+
+func TestParseHumanNumber(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantOrder int
+		wantNum   float64
+	}{
+		{"plain number", "1023", 0, 1023},
+		{"kilo", "1K", 1, 1},
+		{"lowercase kilo", "2k", 1, 2},
+		{"IEC suffix", "1Ki", 1, 1},
+		{"fraction with suffix", "1.5G", 3, 1.5},
+		{"leading blanks", "  12M", 2, 12},
+		{"negative", "-2K", -1, -2},
+		{"zero with suffix", "0K", 0, 0},
+		{"yotta", "1Y", 8, 1},
+		{"not a number", "abc", 0, 0},
+		{"empty", "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, num := ParseHumanNumber(tt.input)
+			if order != tt.wantOrder || num != tt.wantNum {
+				t.Errorf("ParseHumanNumber(%q) = (%d, %v), want (%d, %v)",
+					tt.input, order, num, tt.wantOrder, tt.wantNum)
+			}
+		})
+	}
+}
+
+// TestHumanNumericSort сравнивает результат -h с выводом GNU sort -h на тех же данных
+func TestHumanNumericSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{
+			name:  "suffix order",
+			input: []string{"1K", "1023", "1Ki", "2M", "1.5G", "512", "1T", "10K", "0.5M", "1P", "1E", "1Z", "1Y"},
+			want:  []string{"512", "1023", "1K", "1Ki", "10K", "0.5M", "2M", "1.5G", "1T", "1P", "1E", "1Z", "1Y"},
+		},
+		{
+			name:  "negative values",
+			input: []string{"-1K", "-2K", "1K", "-1M", "0", "-0.5", "5"},
+			want:  []string{"-1M", "-2K", "-1K", "-0.5", "0", "5", "1K"},
+		},
+		{
+			name:  "non-numbers sort as zero",
+			input: []string{"abc", "1K", "", "0", "-1", "xyz", "0K", "2"},
+			want:  []string{"-1", "", "0", "0K", "abc", "xyz", "2", "1K"},
+		},
+		{
+			name:  "du -h output",
+			input: []string{" 4.0K", "  12K", "1.2M", " 980K", "   8.0K", "156M"},
+			want:  []string{" 4.0K", "   8.0K", "  12K", " 980K", "1.2M", "156M"},
+		},
+		{
+			name:  "kilo case",
+			input: []string{"1k", "1K", "2k", "999"},
+			want:  []string{"999", "1K", "1k", "2k"},
+		},
+	}
+
+	config := &parsingflags.Config{Ordering: parsingflags.Ordering{HumanNumeric: true}, Parallel: 1}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SortLines(tt.input, config)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SortLines(-h) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNumericSort сравнивает результат -n с выводом GNU sort: числа сравниваются точно,
+// поэтому соседние большие целые не становятся равными и не отдают порядок следующему ключу
+func TestNumericSort(t *testing.T) {
+	numeric := parsingflags.Ordering{Numeric: true}
+	multiKey := []parsingflags.KeySpec{
+		{StartField: 1, StartChar: 1, EndField: 1, Ordering: numeric},
+		{StartField: 2, StartChar: 1, EndField: 2},
+	}
+
+	tests := []struct {
+		name   string
+		input  []string
+		config *parsingflags.Config
+		want   []string
+	}{
+		{
+			name:   "large integers with second key",
+			input:  []string{"12345678901234567891 a", "12345678901234567890 b"},
+			config: &parsingflags.Config{Keys: multiKey, Parallel: 1},
+			want:   []string{"12345678901234567890 b", "12345678901234567891 a"},
+		},
+		{
+			name:   "beyond float64 precision",
+			input:  []string{"9007199254740993 a", "-9007199254740992 c", "9007199254740992 b", "-9007199254740993 d"},
+			config: &parsingflags.Config{Keys: multiKey, Parallel: 1},
+			want:   []string{"-9007199254740993 d", "-9007199254740992 c", "9007199254740992 b", "9007199254740993 a"},
+		},
+		{
+			name:   "fractions and zeros",
+			input:  []string{"0.50", ".05", "-0", "0010", "9.999", "-.5", "x", "10.0001"},
+			config: &parsingflags.Config{Ordering: numeric, Parallel: 1},
+			want:   []string{"-.5", "-0", "x", ".05", "0.50", "9.999", "0010", "10.0001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SortLines(tt.input, tt.config)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SortLines(-n) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkSortLines измеряет сортировку с ключами, разобранными один раз на строку
+func BenchmarkSortLines(b *testing.B) {
+	lines := generateLines(benchmarkLines)
+	for name, config := range benchmarkConfigs() {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SortLines(lines, config)
+			}
+		})
+	}
+}
+
+// BenchmarkSortPerComparison измеряет прежний подход, при котором ключи
+// извлекаются и разбираются заново при каждом сравнении
+func BenchmarkSortPerComparison(b *testing.B) {
+	lines := generateLines(benchmarkLines)
+	for name, config := range benchmarkConfigs() {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sorted := make([]string, len(lines))
+				copy(sorted, lines)
+				sort.Slice(sorted, func(i, j int) bool {
+					return CompareStrings(sorted[i], sorted[j], config)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package sorting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	parsingflags "main/parsingFlags"
+	readinput "main/readInput"
+	"runtime"
+	"slices"
+)
+
+// KeyExtractor выделяет ключ сортировки из записи без разделителя.
+// Функция вызывается из нескольких горутин и не должна изменять запись
+type KeyExtractor func(record []byte) []byte
+
+// Comparator сравнивает ключи и возвращает отрицательное число, 0 или положительное число
+type Comparator func(a, b []byte) int
+
+// Sorter сортирует записи потоков и файлов. Вход, не помещающийся в бюджет памяти,
+// сортируется частями во временные файлы. Sorter можно использовать повторно
+// и из нескольких горутин одновременно
+type Sorter struct {
+	config     parsingflags.Config
+	customKeys []customKey
+	err        error // ошибка настроек, ее возвращают Sort, SortFiles, Merge и Check
+}
+
+// customKey — ключ, заданный через WithKey: extract выделяет его из записи, compare сравнивает
+type customKey struct {
+	extract KeyExtractor
+	compare Comparator
+}
+
+// settings — конфиг сортировки вместе с пользовательскими ключами Sorter.
+// Флаги командной строки задают только Config, поэтому ключи хранятся отдельно
+type settings struct {
+	*parsingflags.Config
+	customKeys []customKey // сравниваются после ключей -k
+}
+
+// plain оборачивает конфиг без пользовательских ключей
+func plain(config *parsingflags.Config) *settings {
+	return &settings{Config: config}
+}
+
+// settings возвращает настройки сортировки Sorter
+func (s *Sorter) settings() *settings {
+	return &settings{Config: &s.config, customKeys: s.customKeys}
+}
+
+// Option настраивает Sorter, созданный через New
+type Option func(*Sorter)
+
+// New создает Sorter с настройками по умолчанию, как у my_sort без флагов:
+// записи разделяются '\n' и сравниваются побайтово целиком
+func New(opts ...Option) *Sorter {
+	s := &Sorter{config: parsingflags.Config{Parallel: runtime.GOMAXPROCS(0)}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.err = normalizeKeys(s.config.Keys)
+	return s
+}
+
+// normalizeKeys проверяет ключи, заданные через WithConfig в обход разбора флагов.
+// Нулевой StartChar, как у незаполненного KeySpec, означает первый символ поля
+func normalizeKeys(keys []parsingflags.KeySpec) error {
+	for i := range keys {
+		key := &keys[i]
+		if key.StartField < 1 {
+			return fmt.Errorf("invalid key %d: field number %d, fields are numbered from 1", i+1, key.StartField)
+		}
+		if key.StartChar < 0 || key.EndField < 0 || key.EndChar < 0 {
+			return fmt.Errorf("invalid key %d: negative position", i+1)
+		}
+		if key.StartChar == 0 {
+			key.StartChar = 1
+		}
+	}
+	return nil
+}
+
+// WithConfig задает все настройки сразу, например разобранные из флагов командной строки.
+// Конфиг копируется, поэтому последующие опции его не меняют. Ключ с StartField меньше 1
+// делает Sorter непригодным: его методы возвращают ошибку
+func WithConfig(config *parsingflags.Config) Option {
+	return func(s *Sorter) {
+		s.config = *config
+		s.config.Keys = slices.Clone(config.Keys)
+		s.config.TempDirs = slices.Clone(config.TempDirs)
+	}
+}
+
+// WithKey добавляет ключ: extract выделяет его из записи, compare сравнивает.
+// Ключи сравниваются в порядке добавления после ключей -k из конфига. Пустой extract
+// означает запись целиком, пустой compare — побайтовое сравнение
+func WithKey(extract KeyExtractor, compare Comparator) Option {
+	if extract == nil {
+		extract = func(record []byte) []byte { return record }
+	}
+	if compare == nil {
+		compare = bytes.Compare
+	}
+	return func(s *Sorter) {
+		s.customKeys = append(s.customKeys, customKey{extract: extract, compare: compare})
+	}
+}
+
+// WithComparator добавляет сравнение записей целиком функцией compare
+func WithComparator(compare Comparator) Option {
+	return WithKey(nil, compare)
+}
+
+// WithReverse обращает порядок сортировки (-r)
+func WithReverse() Option {
+	return func(s *Sorter) { s.config.Reverse = true }
+}
+
+// WithStable отключает последнее сравнение записей целиком: записи с равными ключами
+// сохраняют порядок входа (-s)
+func WithStable() Option {
+	return func(s *Sorter) { s.config.Stable = true }
+}
+
+// WithUnique оставляет первую запись из каждой группы с равными ключами (-u)
+func WithUnique() Option {
+	return func(s *Sorter) { s.config.Unique = true }
+}
+
+// WithRepeated выводит по одной записи только из групп с равными ключами
+// размером больше одной (--repeated)
+func WithRepeated() Option {
+	return func(s *Sorter) { s.config.Repeated = true }
+}
+
+// WithCount выводит по одной записи из каждой группы с равными ключами
+// с размером группы в начале строки, как uniq -c (--count)
+func WithCount() Option {
+	return func(s *Sorter) { s.config.Count = true }
+}
+
+// WithBufferSize ограничивает память под сортировку частей входа n байтами (-S)
+func WithBufferSize(n int64) Option {
+	return func(s *Sorter) { s.config.BufferSize = n }
+}
+
+// WithTempDir добавляет каталог для временных файлов (-T)
+func WithTempDir(dir string) Option {
+	return func(s *Sorter) { s.config.TempDirs = append(s.config.TempDirs, dir) }
+}
+
+// WithTop оставляет только первые n записей в порядке сортировки (--top)
+func WithTop(n int) Option {
+	return func(s *Sorter) { s.config.Top, s.config.Bottom = n, 0 }
+}
+
+// WithBottom оставляет только последние n записей в порядке сортировки (--bottom)
+func WithBottom(n int) Option {
+	return func(s *Sorter) { s.config.Bottom, s.config.Top = n, 0 }
+}
+
+// WithParallel задает число горутин для сортировки в памяти (--parallel)
+func WithParallel(n int) Option {
+	return func(s *Sorter) { s.config.Parallel = max(n, 1) }
+}
+
+// Sort читает записи из r, сортирует их и пишет в w. При отмене ctx сортировка
+// прерывается, временные файлы удаляются, а возвращается ошибка контекста
+func (s *Sorter) Sort(ctx context.Context, r io.Reader, w io.Writer) error {
+	return s.sort(ctx, func(fn func(line string) error) (bool, error) {
+		return readinput.ReadRecords(r, s.config.Terminator(), s.config.CSVComma(), fn)
+	}, w)
+}
+
+// SortFiles сортирует записи всех файлов вместе и пишет результат в w.
+// Без файлов, как sort, читает stdin
+func (s *Sorter) SortFiles(ctx context.Context, files []string, w io.Writer) error {
+	return s.sort(ctx, func(fn func(line string) error) (bool, error) {
+		return readinput.ForEachLine(files, s.config.Terminator(), s.config.CSVComma(), fn)
+	}, w)
+}
+
+// sort выбирает отбор --top/--bottom в ограниченной куче или полную сортировку
+func (s *Sorter) sort(ctx context.Context, source recordSource, w io.Writer) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.config.Top > 0 || s.config.Bottom > 0 {
+		return selectTop(ctx, source, s.settings(), w)
+	}
+	return externalSort(ctx, source, s.settings(), w)
+}
+
+// Merge сливает уже отсортированные файлы (-m) без повторной сортировки и пишет результат в w.
+// Без файлов читает stdin
+func (s *Sorter) Merge(ctx context.Context, files []string, w io.Writer) error {
+	if s.err != nil {
+		return s.err
+	}
+	return mergeFiles(ctx, files, s.settings(), w)
+}
+
+// Check проверяет, что записи из r уже упорядочены (-c), как CheckSorted, но с настройками
+// Sorter, включая ключи WithKey, и прерывается при отмене ctx
+func (s *Sorter) Check(ctx context.Context, r io.Reader) error {
+	if s.err != nil {
+		return s.err
+	}
+	return checkSorted(ctx, r, s.settings())
+}
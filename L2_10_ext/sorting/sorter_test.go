@@ -0,0 +1,364 @@
+package sorting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	parsingflags "main/parsingFlags"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSorterCustomKey проверяет пользовательские ключ и компаратор и их сочетание с -r и -u
+func TestSorterCustomKey(t *testing.T) {
+	// Ключ — длина второго поля через ':'
+	byLength := func(record []byte) []byte {
+		_, value, _ := bytes.Cut(record, []byte(":"))
+		return value
+	}
+	compareLength := func(a, b []byte) int { return len(a) - len(b) }
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+		want  string
+	}{
+		{
+			name:  "comparator",
+			input: "ccc\na\nbb\n",
+			opts:  []Option{WithComparator(compareLength)},
+			want:  "a\nbb\nccc\n",
+		},
+		{
+			name:  "key and last-resort compare",
+			input: "x:aaa\nb:z\na:z\ny:bb\n",
+			opts:  []Option{WithKey(byLength, compareLength)},
+			want:  "a:z\nb:z\ny:bb\nx:aaa\n",
+		},
+		{
+			name:  "stable reverse",
+			input: "b:z\nx:aaa\na:z\n",
+			opts:  []Option{WithKey(byLength, compareLength), WithStable(), WithReverse()},
+			want:  "x:aaa\nb:z\na:z\n",
+		},
+		{
+			name:  "unique",
+			input: "b\na\nb\n",
+			opts:  []Option{WithUnique()},
+			want:  "a\nb\n",
+		},
+		{
+			name:  "unique by key keeps first",
+			input: "b:zz\na:x\nc:zz\n",
+			opts:  []Option{WithKey(byLength, compareLength), WithUnique()},
+			want:  "a:x\nb:zz\n",
+		},
+		{
+			name:  "numeric unique",
+			input: "01\n2\n1\n002\n",
+			opts:  []Option{WithConfig(&parsingflags.Config{Ordering: parsingflags.Ordering{Numeric: true}}), WithUnique()},
+			want:  "01\n2\n",
+		},
+		{
+			name:  "numeric unique large integers",
+			input: "9007199254740993\n9007199254740992\n12345678901234567891\n12345678901234567890\n9007199254740993\n",
+			opts:  []Option{WithConfig(&parsingflags.Config{Ordering: parsingflags.Ordering{Numeric: true}}), WithUnique()},
+			want:  "9007199254740992\n9007199254740993\n12345678901234567890\n12345678901234567891\n",
+		},
+		{
+			name:  "human unique fractions",
+			input: "1.00000000000000001K\n1K\n1.0K\n",
+			opts:  []Option{WithConfig(&parsingflags.Config{Ordering: parsingflags.Ordering{HumanNumeric: true}}), WithUnique()},
+			want:  "1K\n1.00000000000000001K\n",
+		},
+		{
+			name:  "count",
+			input: "b\na\nb\nc\nb\n",
+			opts:  []Option{WithCount()},
+			want:  "      1 a\n      3 b\n      1 c\n",
+		},
+		{
+			name:  "repeated",
+			input: "b\na\nb\nc\nc\n",
+			opts:  []Option{WithRepeated()},
+			want:  "b\nc\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := New(test.opts...).Sort(context.Background(), strings.NewReader(test.input), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+		})
+	}
+}
+
+// TestSorterCanceled проверяет, что отмененная сортировка возвращает ошибку контекста
+// и не оставляет временных файлов
+func TestSorterCanceled(t *testing.T) {
+	input, _ := writeLogInput(t, 50_000)
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	err := New(WithBufferSize(minChunkSize), WithTempDir(dir)).SortFiles(ctx, []string{input}, &out)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("%d temporary files left", len(left))
+	}
+}
+
+// TestSorterTop проверяет, что --top и --bottom совпадают с началом и концом полной сортировки
+func TestSorterTop(t *testing.T) {
+	lines := generateLines(10_000)
+	input := strings.Join(lines, "\n") + "\n"
+
+	for name, config := range benchmarkConfigs() {
+		for _, unique := range []bool{false, true} {
+			config := *config
+			config.Unique = unique
+
+			var full bytes.Buffer
+			if err := New(WithConfig(&config)).Sort(context.Background(), strings.NewReader(input), &full); err != nil {
+				t.Fatal(err)
+			}
+			sorted := strings.SplitAfter(full.String(), "\n")
+			sorted = sorted[:len(sorted)-1]
+
+			for _, n := range []int{1, 10, len(sorted) + 1} {
+				var top, bottom bytes.Buffer
+				if err := New(WithConfig(&config), WithTop(n)).Sort(context.Background(), strings.NewReader(input), &top); err != nil {
+					t.Fatal(err)
+				}
+				if err := New(WithConfig(&config), WithBottom(n)).Sort(context.Background(), strings.NewReader(input), &bottom); err != nil {
+					t.Fatal(err)
+				}
+
+				k := min(n, len(sorted))
+				if want := strings.Join(sorted[:k], ""); top.String() != want {
+					t.Errorf("%s unique=%v: --top %d differs from sort | head", name, unique, n)
+				}
+				if want := strings.Join(sorted[len(sorted)-k:], ""); bottom.String() != want {
+					t.Errorf("%s unique=%v: --bottom %d differs from sort | tail", name, unique, n)
+				}
+			}
+		}
+	}
+}
+
+// TestSorterCSV проверяет --csv и --header: записи в кавычках, занимающие несколько строк,
+// разделитель внутри кавычек, пустые строки и кавычки внутри поля без кавычек
+func TestSorterCSV(t *testing.T) {
+	field := func(n int, ordering parsingflags.Ordering) []parsingflags.KeySpec {
+		return []parsingflags.KeySpec{{StartField: n, StartChar: 1, EndField: n, Ordering: ordering}}
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		config *parsingflags.Config
+		top    int
+		want   string
+	}{
+		{
+			name:   "multi-line quoted record",
+			input:  "b,\"x\ny\"\na,z\n",
+			config: &parsingflags.Config{CSV: true, Keys: field(1, parsingflags.Ordering{})},
+			want:   "a,z\nb,\"x\ny\"\n",
+		},
+		{
+			name:   "separator inside quotes",
+			input:  "1,\"b,a\",3\n2,a,1\n",
+			config: &parsingflags.Config{CSV: true, Keys: field(3, parsingflags.Ordering{Numeric: true})},
+			want:   "2,a,1\n1,\"b,a\",3\n",
+		},
+		{
+			name:   "blank lines",
+			input:  "b\n\na\n",
+			config: &parsingflags.Config{CSV: true},
+			want:   "\na\nb\n",
+		},
+		{
+			name:   "bare quote",
+			input:  "x,ab\"c\nb,1\n",
+			config: &parsingflags.Config{CSV: true, Keys: field(2, parsingflags.Ordering{})},
+			want:   "b,1\nx,ab\"c\n",
+		},
+		{
+			name:   "header",
+			input:  "name,n\nb,\"2\n\"\na,1\n",
+			config: &parsingflags.Config{CSV: true, Header: 1},
+			want:   "name,n\na,1\nb,\"2\n\"\n",
+		},
+		{
+			name:   "header with top",
+			input:  "name,n\nz,1\n\"a,x\",2\nb,0\n",
+			config: &parsingflags.Config{CSV: true, Header: 1, Keys: field(2, parsingflags.Ordering{Numeric: true})},
+			top:    1,
+			want:   "name,n\nb,0\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := []Option{WithConfig(test.config)}
+			if test.top > 0 {
+				opts = append(opts, WithTop(test.top))
+			}
+
+			var out bytes.Buffer
+			if err := New(opts...).Sort(context.Background(), strings.NewReader(test.input), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+		})
+	}
+}
+
+// TestSorterCheckCSVHeader проверяет -c с --csv и --header: заголовок не проверяется,
+// а номер нарушающей записи считается в CSV-записях, а не в строках
+func TestSorterCheckCSVHeader(t *testing.T) {
+	sorter := New(WithConfig(&parsingflags.Config{CSV: true, Header: 1}))
+
+	if err := sorter.Check(context.Background(), strings.NewReader("z,h\na,\"1\n2\"\nb,3\n")); err != nil {
+		t.Errorf("sorted input: unexpected error: %v", err)
+	}
+
+	err := sorter.Check(context.Background(), strings.NewReader("h,h\nz,\"q\nr\"\na,2\n"))
+	var disorder *DisorderError
+	if !errors.As(err, &disorder) {
+		t.Fatalf("got %v, want *DisorderError", err)
+	}
+	if disorder.Line != 3 || disorder.Text != "a,2" {
+		t.Errorf("got disorder at %d %q, want 3 %q", disorder.Line, disorder.Text, "a,2")
+	}
+}
+
+// TestSorterKeySpec проверяет ключи, заданные через WithConfig без разбора флагов:
+// нулевой StartChar означает начало поля, а нулевой StartField — ошибку, а не панику
+func TestSorterKeySpec(t *testing.T) {
+	numeric := parsingflags.Ordering{Numeric: true}
+
+	sorter := New(WithConfig(&parsingflags.Config{Keys: []parsingflags.KeySpec{{StartField: 2, EndField: 2, Ordering: numeric}}}))
+	var out bytes.Buffer
+	if err := sorter.Sort(context.Background(), strings.NewReader("x 10\ny 9\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "y 9\nx 10\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	sorter = New(WithConfig(&parsingflags.Config{Keys: []parsingflags.KeySpec{{Ordering: numeric}}}))
+	if err := sorter.Sort(context.Background(), strings.NewReader("b\na\n"), io.Discard); err == nil {
+		t.Error("Sort: want error for field number 0")
+	}
+	if err := sorter.Merge(context.Background(), nil, io.Discard); err == nil {
+		t.Error("Merge: want error for field number 0")
+	}
+	if err := sorter.Check(context.Background(), strings.NewReader("a\nb\n")); err == nil {
+		t.Error("Check: want error for field number 0")
+	}
+}
+
+// TestSorterFinalTerminator проверяет, что полная сортировка и --top/--bottom одинаково
+// завершают вывод: разделитель в конце есть, только если им завершался вход
+func TestSorterFinalTerminator(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "terminated", input: "b\nc\na\n", want: "a\nb\nc\n"},
+		{name: "unterminated", input: "b\nc\na", want: "a\nb\nc"},
+		{name: "unterminated record sorted first", input: "c\nd\nb\na", want: "a\nb\nc\nd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, opts := range map[string][]Option{
+				"sort":   nil,
+				"top":    {WithTop(10)},
+				"bottom": {WithBottom(10)},
+			} {
+				var out bytes.Buffer
+				if err := New(opts...).Sort(context.Background(), strings.NewReader(test.input), &out); err != nil {
+					t.Fatal(err)
+				}
+				if out.String() != test.want {
+					t.Errorf("%s: got %q, want %q", name, out.String(), test.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSorterStdinOperand проверяет, что операнд "-" читается из stdin наравне с файлами,
+// как в sort -m shard.txt -, и что stdin после этого не закрыт
+func TestSorterStdinOperand(t *testing.T) {
+	dir := t.TempDir()
+	shard := filepath.Join(dir, "shard.txt")
+	if err := os.WriteFile(shard, []byte("a\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+
+	tests := []struct {
+		name string
+		run  func(s *Sorter, w io.Writer) error
+		want string
+	}{
+		{
+			name: "merge",
+			run:  func(s *Sorter, w io.Writer) error { return s.Merge(context.Background(), []string{shard, "-"}, w) },
+			want: "a\nb\nc\nd\n",
+		},
+		{
+			name: "sort files",
+			run:  func(s *Sorter, w io.Writer) error { return s.SortFiles(context.Background(), []string{"-", shard}, w) },
+			want: "a\nb\nc\nd\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := filepath.Join(dir, "stdin.txt")
+			if err := os.WriteFile(input, []byte("b\nd\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			os.Stdin = file
+
+			var out bytes.Buffer
+			if err := test.run(New(), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+			if _, err := file.Stat(); err != nil {
+				t.Errorf("stdin closed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package sorting
+
+import (
+	"os"
+	"slices"
+	"sync"
+)
+
+// tempFiles создает временные файлы чанков по очереди во всех каталогах -T
+// и запоминает их, чтобы удалить и при обычном завершении, и при ошибке или отмене
+type tempFiles struct {
+	mu    sync.Mutex
+	dirs  []string
+	names []string
+	next  int
+}
+
+// newTempFiles создает реестр временных файлов; без каталогов используется os.TempDir
+func newTempFiles(dirs []string) *tempFiles {
+	if len(dirs) == 0 {
+		dirs = []string{""}
+	}
+	return &tempFiles{dirs: dirs}
+}
+
+// create создает временный файл в следующем по очереди каталоге
+func (t *tempFiles) create() (*os.File, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	dir := t.dirs[t.next%len(t.dirs)]
+	t.next++
+
+	file, err := os.CreateTemp(dir, "my_sort_chunk_*")
+	if err != nil {
+		return nil, err
+	}
+	t.names = append(t.names, file.Name())
+	return file, nil
+}
+
+// remove удаляет временный файл name, если он был создан этим реестром
+func (t *tempFiles) remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if i := slices.Index(t.names, name); i >= 0 {
+		os.Remove(name)
+		t.names = slices.Delete(t.names, i, i+1)
+	}
+}
+
+// removeAll удаляет все созданные временные файлы
+func (t *tempFiles) removeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, name := range t.names {
+		os.Remove(name)
+	}
+	t.names = nil
+}
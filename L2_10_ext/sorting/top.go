@@ -0,0 +1,150 @@
+package sorting
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"io"
+	writeoutput "main/writeOutput"
+	"slices"
+)
+
+// topItem — запись, отобранная для --top или --bottom, с номером во входе
+type topItem struct {
+	record Record
+	seq    int
+}
+
+// topHeap хранит не более limit лучших записей. В корне лежит худшая из них:
+// для --top наибольшая, для --bottom наименьшая, поэтому новая запись сравнивается только с корнем
+type topHeap struct {
+	items  []topItem
+	limit  int
+	bottom bool
+	config *settings
+}
+
+// order сравнивает записи компаратором сортировки, а равные — по порядку во входе,
+// поэтому результат совпадает с sort | head и sort | tail
+func (h *topHeap) order(a, b *topItem) int {
+	return cmp.Or(compareRecords(&a.record, &b.record, h.config), cmp.Compare(a.seq, b.seq))
+}
+
+func (h *topHeap) Len() int { return len(h.items) }
+
+func (h *topHeap) Less(i, j int) bool {
+	if h.bottom {
+		return h.order(&h.items[i], &h.items[j]) < 0
+	}
+	return h.order(&h.items[i], &h.items[j]) > 0
+}
+
+func (h *topHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *topHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(topItem))
+}
+
+func (h *topHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	x := old[n-1]
+	h.items = old[0 : n-1]
+	return x
+}
+
+// add предлагает запись куче: она сохраняется, если лучше худшей из отобранных
+func (h *topHeap) add(item topItem) {
+	if h.config.Unique {
+		h.addUnique(item)
+		return
+	}
+
+	if len(h.items) == h.limit {
+		result := h.order(&item, &h.items[0])
+		if (!h.bottom && result >= 0) || (h.bottom && result <= 0) {
+			return
+		}
+	}
+
+	if len(h.items) < h.limit {
+		heap.Push(h, item)
+		return
+	}
+	h.items[0] = item
+	heap.Fix(h, 0)
+}
+
+// addUnique отбирает записи для -u. Найти в куче запись, равную новой, можно только
+// перебором, поэтому записи копятся в буфере до 2N и периодически сжимаются в compact:
+// время остается O(n log N), а память — O(N)
+func (h *topHeap) addUnique(item topItem) {
+	h.items = append(h.items, item)
+	if len(h.items) >= 2*h.limit {
+		h.compact()
+	}
+}
+
+// compact сортирует буфер -u, оставляет из равных записей первую во входе, как sort -u,
+// и отбрасывает все, кроме N лучших
+func (h *topHeap) compact() {
+	slices.SortFunc(h.items, func(a, b topItem) int { return h.order(&a, &b) })
+	h.items = slices.CompactFunc(h.items, func(a, b topItem) bool {
+		return compareRecords(&a.record, &b.record, h.config) == 0
+	})
+	if len(h.items) > h.limit {
+		if h.bottom {
+			h.items = slices.Delete(h.items, 0, len(h.items)-h.limit)
+		} else {
+			h.items = slices.Delete(h.items, h.limit, len(h.items))
+		}
+	}
+}
+
+// selectTop потоково отбирает первые --top или последние --bottom записей в порядке
+// сортировки, храня в памяти не больше N записей: время O(n log N), память O(N)
+func selectTop(ctx context.Context, source recordSource, config *settings, outputWriter io.Writer) error {
+	h := &topHeap{limit: config.Top, config: config}
+	if config.Bottom > 0 {
+		h.limit, h.bottom = config.Bottom, true
+	}
+
+	var header []string
+	count := 0
+	terminated, err := source(func(line string) error {
+		if count++; count%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if len(header) < config.Header {
+			header = append(header, line)
+			return nil
+		}
+		h.add(topItem{record: newRecord(line, config), seq: count})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if config.Unique {
+		h.compact()
+	}
+	selected := h.items
+	slices.SortFunc(selected, func(a, b topItem) int { return h.order(&a, &b) })
+
+	writer := writeoutput.NewRecordWriter(outputWriter, config.Terminator())
+	if err := writeLines(writer, header); err != nil {
+		return err
+	}
+	setDebugMarks(writer, config)
+	for _, item := range selected {
+		if err := writer.Write(item.record.Line); err != nil {
+			return err
+		}
+	}
+
+	// Как и при полной сортировке, вывод завершается разделителем, только если им завершался вход
+	return writer.Finish(terminated)
+}
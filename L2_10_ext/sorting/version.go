@@ -0,0 +1,131 @@
+package sorting
+
+import "cmp"
+
+// compareVersions сравнивает строки как номера версий (-V), как filevercmp из GNU:
+// числа внутри текста сравниваются по значению, поэтому v1.9 < v1.10,
+// а суффиксы вида .tar.gz учитываются только при равенстве остальной части
+func compareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+
+	// Скрытые файлы идут раньше остальных
+	hiddenA, hiddenB := a[0] == '.', b[0] == '.'
+	if hiddenA != hiddenB {
+		if hiddenA {
+			return -1
+		}
+		return 1
+	}
+	if hiddenA {
+		a, b = a[1:], b[1:]
+	}
+
+	prefixA, prefixB := a[:versionPrefixLen(a)], b[:versionPrefixLen(b)]
+	if prefixA != prefixB {
+		if result := verrevcmp(prefixA, prefixB); result != 0 {
+			return result
+		}
+	}
+	return verrevcmp(a, b)
+}
+
+// versionPrefixLen возвращает длину строки без суффикса (\.[A-Za-z~][A-Za-z0-9~]*)*
+func versionPrefixLen(s string) int {
+	for pos := 0; pos < len(s); pos++ {
+		if s[pos] == '.' && isVersionSuffix(s[pos:]) {
+			return pos
+		}
+	}
+	return len(s)
+}
+
+// isVersionSuffix сообщает, состоит ли строка целиком из расширений файла
+func isVersionSuffix(s string) bool {
+	for len(s) > 0 {
+		if len(s) < 2 || s[0] != '.' || !(isAlpha(s[1]) || s[1] == '~') {
+			return false
+		}
+		i := 2
+		for i < len(s) && (isAlpha(s[i]) || isDigit(s[i]) || s[i] == '~') {
+			i++
+		}
+		s = s[i:]
+	}
+	return true
+}
+
+// verrevcmp сравнивает чередующиеся нечисловые и числовые части строк, как dpkg.
+// Буквы идут раньше прочих символов, а '~' раньше всего, даже конца строки
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			orderA, orderB := versionOrder(a, i), versionOrder(b, j)
+			if orderA != orderB {
+				return cmp.Compare(orderA, orderB)
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && j < len(b) && isDigit(a[i]) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return cmp.Compare(firstDiff, 0)
+		}
+	}
+	return 0
+}
+
+// versionOrder возвращает вес символа s[pos] в нечисловой части версии
+func versionOrder(s string, pos int) int {
+	if pos >= len(s) {
+		return 0
+	}
+	c := s[pos]
+	switch {
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isAlpha(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
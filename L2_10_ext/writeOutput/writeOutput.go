@@ -0,0 +1,198 @@
+package writeoutput
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// newFileMode — права нового файла вывода до применения umask, как у os.Create
+const newFileMode = 0666
+
+// Output буферизует вывод в stdout или во временный файл рядом с целевым.
+// Временный файл заменяет целевой только в Close, поэтому вход и вывод
+// могут быть одним и тем же файлом
+type Output struct {
+	*bufio.Writer
+	file *os.File // nil для stdout
+	path string   // целевой файл; пустой, если запись идет напрямую в file
+}
+
+// Open создает вывод в файл path, пустой path означает stdout. Если path —
+// символическая ссылка, заменяется файл, на который она указывает
+func Open(path string) (*Output, error) {
+	if path == "" {
+		return &Output{Writer: bufio.NewWriter(os.Stdout)}, nil
+	}
+
+	path, err := resolveSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err == nil && !info.Mode().IsRegular() {
+		// Устройства и каналы нельзя подменить переименованием, в них пишем напрямую
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &Output{Writer: bufio.NewWriter(file), file: file}, nil
+	}
+
+	file, err := createTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	// Существующий файл сохраняет свои права, новый получает права по umask
+	if info != nil {
+		if err := file.Chmod(info.Mode().Perm()); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, err
+		}
+	}
+
+	return &Output{Writer: bufio.NewWriter(file), file: file, path: path}, nil
+}
+
+// maxSymlinks ограничивает длину цепочки ссылок, как ELOOP в ядре
+const maxSymlinks = 40
+
+// resolveSymlinks возвращает путь к файлу, на который в итоге указывает path.
+// В отличие от filepath.EvalSymlinks, допускает ссылку на еще не созданный файл:
+// тогда вывод создаст его, как при обычной записи через ссылку
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < maxSymlinks; i++ {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+}
+
+// createTemp создает новый временный файл в dir. В отличие от os.CreateTemp,
+// который всегда создает файл с правами 0600, права задаются newFileMode и umask
+func createTemp(dir, prefix string) (*os.File, error) {
+	for {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(rand.Uint64(), 36))
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, newFileMode)
+		if !os.IsExist(err) {
+			return file, err
+		}
+	}
+}
+
+// Close сбрасывает буфер и атомарно переименовывает временный файл в целевой.
+// Ошибки записи, например нехватка места на диске, возвращаются вызывающему
+func (o *Output) Close() error {
+	if err := o.Flush(); err != nil {
+		o.Abort()
+		return err
+	}
+	if o.file == nil {
+		return nil
+	}
+	if o.path == "" {
+		return o.file.Close()
+	}
+
+	if err := o.file.Sync(); err != nil {
+		o.Abort()
+		return err
+	}
+	if err := o.file.Close(); err != nil {
+		os.Remove(o.file.Name())
+		return err
+	}
+	if err := os.Rename(o.file.Name(), o.path); err != nil {
+		os.Remove(o.file.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort удаляет временный файл, оставляя целевой без изменений
+func (o *Output) Abort() {
+	if o.file == nil {
+		return
+	}
+	o.file.Close()
+	if o.path != "" {
+		os.Remove(o.file.Name())
+	}
+}
+
+// RecordWriter пишет записи, разделяя их терминатором. Завершающий терминатор
+// добавляется в Finish, поэтому вывод может повторить вход без него в конце
+type RecordWriter struct {
+	writer   *bufio.Writer
+	delim    byte
+	pending  bool
+	annotate func(record string) string
+}
+
+// NewRecordWriter создает буферизованный писатель записей с терминатором delim
+func NewRecordWriter(w io.Writer, delim byte) *RecordWriter {
+	return &RecordWriter{writer: bufio.NewWriter(w), delim: delim}
+}
+
+// SetAnnotate включает пояснения к записям (--debug): после каждой записи сразу
+// выводятся терминатор и текст annotate(record)
+func (rw *RecordWriter) SetAnnotate(annotate func(record string) string) {
+	rw.annotate = annotate
+}
+
+// Write пишет одну запись, терминатор предыдущей записи выводится перед ней
+func (rw *RecordWriter) Write(record string) error {
+	if rw.annotate != nil {
+		if _, err := rw.writer.WriteString(record); err != nil {
+			return err
+		}
+		if err := rw.writer.WriteByte(rw.delim); err != nil {
+			return err
+		}
+		_, err := rw.writer.WriteString(rw.annotate(record))
+		return err
+	}
+
+	if rw.pending {
+		if err := rw.writer.WriteByte(rw.delim); err != nil {
+			return err
+		}
+	}
+	rw.pending = true
+	_, err := rw.writer.WriteString(record)
+	return err
+}
+
+// Finish завершает последнюю запись терминатором, если terminated, и сбрасывает буфер
+func (rw *RecordWriter) Finish(terminated bool) error {
+	if rw.pending && terminated {
+		if err := rw.writer.WriteByte(rw.delim); err != nil {
+			return err
+		}
+	}
+	rw.pending = false
+	return rw.writer.Flush()
+}
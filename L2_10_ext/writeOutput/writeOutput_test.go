@@ -0,0 +1,77 @@
+package writeoutput
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// writeFile пишет data через Output в path
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	out, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOpenSymlink проверяет, что вывод в символическую ссылку заменяет файл, на
+// который она указывает, а сама ссылка остается ссылкой
+func TestOpenSymlink(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real")
+	if err := os.WriteFile(real, []byte("old\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "sub", "link")
+	if err := os.Symlink("../real", link); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, link, "new\n")
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink", link)
+	}
+	data, err := os.ReadFile(real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("target contains %q, want %q", data, "new\n")
+	}
+	if info, _ := os.Stat(real); info.Mode().Perm() != 0640 {
+		t.Errorf("target mode = %v, want 0640", info.Mode().Perm())
+	}
+}
+
+// TestOpenNewFileUmask проверяет, что новый файл вывода получает права по umask
+func TestOpenNewFileUmask(t *testing.T) {
+	old := syscall.Umask(027)
+	defer syscall.Umask(old)
+
+	path := filepath.Join(t.TempDir(), "out")
+	writeFile(t, path, "x\n")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("new file mode = %v, want 0640", info.Mode().Perm())
+	}
+}
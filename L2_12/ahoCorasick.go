@@ -0,0 +1,111 @@
+package main
+
+// ahoCorasick - автомат Ахо-Корасик для одновременного поиска множества
+// фиксированных строк за один проход по строке ввода
+type ahoCorasick struct {
+	nodes []acNode
+}
+
+// acNode - узел бора шаблонов
+type acNode struct {
+	children map[byte]int32
+	fail     int32 // узел наибольшего собственного суффикса, который есть в боре
+	dict     int32 // ближайший по ссылкам fail узел, где заканчивается шаблон, или -1
+	depth    int32
+	terminal bool // в этом узле заканчивается шаблон
+}
+
+// newAhoCorasick строит автомат по списку шаблонов
+func newAhoCorasick(patterns []string) *ahoCorasick {
+	ac := &ahoCorasick{nodes: []acNode{{dict: -1}}}
+
+	for _, pattern := range patterns {
+		node := int32(0)
+		for i := 0; i < len(pattern); i++ {
+			next, ok := ac.nodes[node].children[pattern[i]]
+			if !ok {
+				next = int32(len(ac.nodes))
+				ac.nodes = append(ac.nodes, acNode{dict: -1, depth: ac.nodes[node].depth + 1})
+				if ac.nodes[node].children == nil {
+					ac.nodes[node].children = make(map[byte]int32)
+				}
+				ac.nodes[node].children[pattern[i]] = next
+			}
+			node = next
+		}
+		ac.nodes[node].terminal = true
+	}
+
+	// Ссылки fail и dict считаются обходом в ширину: у узла на глубине d они
+	// указывают на узлы меньшей глубины, уже обработанные к этому моменту
+	queue := make([]int32, 0, len(ac.nodes))
+	for _, child := range ac.nodes[0].children {
+		ac.setDict(child)
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for b, child := range ac.nodes[node].children {
+			fail := ac.nodes[node].fail
+			for {
+				if next, ok := ac.nodes[fail].children[b]; ok {
+					ac.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = ac.nodes[fail].fail
+			}
+			ac.setDict(child)
+			queue = append(queue, child)
+		}
+	}
+
+	return ac
+}
+
+// setDict вычисляет ссылку на ближайший шаблон-суффикс узла
+func (ac *ahoCorasick) setDict(node int32) {
+	fail := ac.nodes[node].fail
+	if ac.nodes[fail].terminal {
+		ac.nodes[node].dict = fail
+	} else {
+		ac.nodes[node].dict = ac.nodes[fail].dict
+	}
+}
+
+// Find перебирает вхождения шаблонов в s в порядке их концов и передает
+// границы каждого в accept. Возвращает true, как только accept согласится.
+func (ac *ahoCorasick) Find(s string, accept func(start, end int) bool) bool {
+	if ac.nodes[0].terminal && accept(0, 0) {
+		return true
+	}
+
+	node := int32(0)
+	for i := 0; i < len(s); i++ {
+		for {
+			if next, ok := ac.nodes[node].children[s[i]]; ok {
+				node = next
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = ac.nodes[node].fail
+		}
+
+		match := node
+		if !ac.nodes[match].terminal {
+			match = ac.nodes[match].dict
+		}
+		for ; match >= 0; match = ac.nodes[match].dict {
+			if accept(i+1-int(ac.nodes[match].depth), i+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,140 +1,266 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
-	"regexp"
-	"strings"
+	"io"
+	"os"
+	"strconv"
+	"sync/atomic"
 )
 
-// MatchResult представляет результат совпадения
-type MatchResult struct {
+// contextLine - строка, ожидающая вывода в качестве контекста перед совпадением
+type contextLine struct {
 	LineNum int
 	Line    string
-	Matched bool
 }
 
-// Grep выполняет поиск по строкам согласно конфигурации
-func Grep(lines []string, config *Config) ([]string, int, error) {
-	pattern := config.Pattern
-	if config.IgnoreCase {
-		if config.Fixed {
-			pattern = strings.ToLower(pattern)
-		} else {
-			pattern = "(?i)" + pattern
-		}
+// Grep выполняет потоковый поиск по файлам: строки читаются по одной,
+// а результат сразу пишется в выходной поток. В памяти хранятся только
+// последние -B строк текущего файла.
+type Grep struct {
+	config *Config
+	match  func(line string) bool
+	out    *bufio.Writer
+	errOut io.Writer // сообщения о совпадениях в двоичных файлах
+
+	matchCount int
+	printed    bool        // выведена ли хотя бы одна строка (для разделителей между файлами)
+	done       atomic.Bool // поиск можно прекратить (-q нашел совпадение)
+}
+
+// fileSearch - состояние поиска в одном файле
+type fileSearch struct {
+	config   *Config
+	match    func(line string) bool
+	out      *bufio.Writer
+	name     string
+	lines    bool // выводятся ли сами строки (а не имена файлов или количество)
+	separate bool // нужен ли разделитель "--" перед первой группой контекста
+	printed  bool
+
+	skipBinary bool // двоичный файл пропускается целиком (найден при обходе каталога)
+	binary     bool // файл двоичный: вместо строк сообщается только о совпадении
+
+	before     []contextLine // кольцевой буфер строк для -B
+	beforeHead int
+	beforeLen  int
+	afterLeft  int // сколько строк контекста -A осталось вывести
+
+	lineNum     int
+	lastPrinted int
+	matchCount  int
+	maxReached  bool
+	stopped     bool
+}
+
+// NewGrep создает поиск по конфигурации, пишущий результат в w
+func NewGrep(config *Config, w io.Writer) (*Grep, error) {
+	match, err := newMatcher(config)
+	if err != nil {
+		return nil, err
 	}
 
-	var re *regexp.Regexp
-	var err error
-	if !config.Fixed {
-		re, err = regexp.Compile(pattern)
-		if err != nil {
-			return nil, 0, fmt.Errorf("invalid regex pattern: %v", err)
-		}
+	g := &Grep{
+		config: config,
+		match:  match,
+		out:    bufio.NewWriter(w),
+		errOut: os.Stderr,
 	}
+	g.done.Store(config.MaxCount == 0)
+	return g, nil
+}
 
-	matches := make([]MatchResult, len(lines))
-	matchCount := 0
-
-	for i, line := range lines {
-		var matched bool
-
-		if config.Fixed {
-			searchLine := line
-			searchPattern := config.Pattern
-			if config.IgnoreCase {
-				searchLine = strings.ToLower(line)
-				searchPattern = strings.ToLower(config.Pattern)
-			}
-			matched = strings.Contains(searchLine, searchPattern)
-		} else {
-			if re != nil {
-				matched = re.MatchString(line)
-			}
-		}
+// Search ищет совпадения в r и сразу выводит их с префиксом name при -H.
+// Состояние контекста и нумерация строк у каждого файла свои.
+func (g *Grep) Search(name string, r io.Reader) error {
+	if g.Done() {
+		return nil
+	}
 
-		if config.Invert {
-			matched = !matched
-		}
+	fs := g.newFileSearch(name, g.out, g.printed)
+	err := fs.run(r)
+	g.add(name, fs.matchCount, fs.printed, fs.binaryMatch())
+	return err
+}
 
-		matches[i] = MatchResult{
-			LineNum: i + 1,
-			Line:    line,
-			Matched: matched,
+// newFileSearch создает поиск в одном файле, пишущий результат в out
+func (g *Grep) newFileSearch(name string, out *bufio.Writer, separate bool) *fileSearch {
+	config := g.config
+	fs := &fileSearch{
+		config:   config,
+		match:    g.match,
+		out:      out,
+		name:     name,
+		lines:    config.PrintsLines(),
+		separate: separate,
+	}
+	if fs.lines {
+		fs.before = make([]contextLine, config.Before)
+	}
+	return fs
+}
+
+// add учитывает итог поиска в очередном файле. О совпадении в двоичном файле,
+// как в GNU grep, сообщается в stderr вместо вывода строк.
+func (g *Grep) add(name string, matchCount int, printed, binary bool) {
+	if binary {
+		g.out.Flush()
+		fmt.Fprintf(g.errOut, "grep: %s: binary file matches\n", name)
+	}
+	g.matchCount += matchCount
+	g.printed = g.printed || printed
+	if g.config.Quiet && matchCount > 0 {
+		g.done.Store(true)
+	}
+}
+
+// run читает r построчно. Двоичные файлы (с нулевым байтом в начале), найденные
+// при обходе каталогов, пропускаются, а в остальных ищется только факт совпадения.
+func (fs *fileSearch) run(r io.Reader) error {
+	reader := bufio.NewReaderSize(r, readBufferSize)
+	if IsBinary(reader) {
+		if fs.skipBinary {
+			return nil
 		}
+		fs.binary = true
+	}
+
+	_, err := ForEachLine(reader, fs.line)
+	fs.finish()
+	return err
+}
 
-		if matched {
-			matchCount++
+// line обрабатывает очередную строку файла. Возвращает false, когда
+// дальнейшее чтение не нужно (-q или -l нашли совпадение, достигнут предел -m).
+func (fs *fileSearch) line(line string) bool {
+	fs.lineNum++
+
+	// После достижения предела -m оставшиеся строки выводятся только как контекст
+	matched := !fs.maxReached && fs.match(line) != fs.config.Invert
+
+	switch {
+	case matched:
+		fs.matchCount++
+		if fs.config.Quiet || fs.config.FilesWithMatches || fs.config.FilesWithoutMatch || fs.binary && fs.lines {
+			fs.stopped = true
+			return false
+		}
+		if fs.lines {
+			fs.flushBefore()
+			fs.printLine(fs.lineNum, line, ':')
+			fs.afterLeft = fs.config.After
+		}
+		if fs.config.MaxCount > 0 && fs.matchCount >= fs.config.MaxCount {
+			fs.maxReached = true
 		}
+	case fs.afterLeft > 0:
+		fs.printLine(fs.lineNum, line, '-')
+		fs.afterLeft--
+	default:
+		fs.pushBefore(line)
 	}
 
-	if config.Count {
-		return []string{fmt.Sprintf("%d", matchCount)}, matchCount, nil
-	}
-
-	return buildOutput(matches, config), matchCount, nil
-}
-
-// buildOutput строит вывод с учетом контекста и номеров строк
-func buildOutput(matches []MatchResult, config *Config) []string {
-	var result []string
-	printed := make(map[int]bool)
-	lastMatch := -1
-
-	for i, match := range matches {
-		if match.Matched {
-			start := i - config.Before
-			if start < 0 {
-				start = 0
-			}
-			for j := start; j < i; j++ {
-				if !printed[j] {
-					if config.Context != 0 && matches[j].LineNum > lastMatch+1 && lastMatch != -1 {
-						result = append(result, "--")
-					}
-					result = appendContextLine(result, matches[j], config, false)
-					lastMatch = matches[i].LineNum
-					printed[j] = true
-				}
-			}
-
-			if !printed[i] {
-				if config.Context != 0 && matches[i].LineNum > lastMatch+1 && lastMatch != -1 {
-					result = append(result, "--")
-				}
-				result = appendContextLine(result, matches[i], config, true)
-				lastMatch = matches[i].LineNum
-				printed[i] = true
-			}
-
-			end := i + config.After + 1
-			if end > len(matches) {
-				end = len(matches)
-			}
-			for j := i + 1; j < end; j++ {
-				if !printed[j] {
-					result = appendContextLine(result, matches[j], config, false)
-					lastMatch = matches[j].LineNum
-					printed[j] = true
-				}
-			}
+	if fs.maxReached && fs.afterLeft == 0 {
+		fs.stopped = true
+	}
+	return !fs.stopped
+}
+
+// finish выводит итог по файлу для -c, -l и -L
+func (fs *fileSearch) finish() {
+	switch {
+	case fs.config.Quiet:
+	case fs.config.FilesWithMatches:
+		if fs.matchCount > 0 {
+			fs.printName()
 		}
+	case fs.config.FilesWithoutMatch:
+		if fs.matchCount == 0 {
+			fs.printName()
+		}
+	case fs.config.Count:
+		if fs.config.WithFilename {
+			fs.out.WriteString(fs.name)
+			fs.out.WriteByte(':')
+		}
+		fs.out.WriteString(strconv.Itoa(fs.matchCount))
+		fs.out.WriteByte('\n')
+	}
+}
+
+// binaryMatch сообщает, что строки двоичного файла совпали, но не были выведены
+func (fs *fileSearch) binaryMatch() bool {
+	return fs.binary && fs.lines && fs.matchCount > 0
+}
+
+// Done сообщает, что поиск завершен досрочно и остальные файлы читать не нужно
+func (g *Grep) Done() bool {
+	return g.done.Load()
+}
+
+// MatchCount возвращает количество найденных строк во всех файлах
+func (g *Grep) MatchCount() int {
+	return g.matchCount
+}
+
+// Flush сбрасывает буфер вывода
+func (g *Grep) Flush() error {
+	return g.out.Flush()
+}
+
+// pushBefore запоминает строку в кольцевом буфере -B, вытесняя самую старую
+func (fs *fileSearch) pushBefore(line string) {
+	size := len(fs.before)
+	if size == 0 {
+		return
 	}
+	if fs.beforeLen < size {
+		fs.before[(fs.beforeHead+fs.beforeLen)%size] = contextLine{LineNum: fs.lineNum, Line: line}
+		fs.beforeLen++
+		return
+	}
+	fs.before[fs.beforeHead] = contextLine{LineNum: fs.lineNum, Line: line}
+	fs.beforeHead = (fs.beforeHead + 1) % size
+}
 
-	return result
+// flushBefore выводит накопленный контекст -B и очищает буфер
+func (fs *fileSearch) flushBefore() {
+	size := len(fs.before)
+	for i := 0; i < fs.beforeLen; i++ {
+		cl := fs.before[(fs.beforeHead+i)%size]
+		fs.printLine(cl.LineNum, cl.Line, '-')
+		fs.before[(fs.beforeHead+i)%size] = contextLine{}
+	}
+	fs.beforeHead = 0
+	fs.beforeLen = 0
 }
 
-// appendContextLine добавляет строку в результат с учетом форматирования
-func appendContextLine(result []string, match MatchResult, config *Config, isMatch bool) []string {
-	line := match.Line
+// printName выводит имя файла для -l и -L
+func (fs *fileSearch) printName() {
+	fs.out.WriteString(fs.name)
+	fs.out.WriteByte('\n')
+}
 
-	if config.LineNum {
-		prefix := fmt.Sprintf("%d-", match.LineNum)
-		if isMatch {
-			prefix = fmt.Sprintf("%d:", match.LineNum)
+// printLine выводит строку с учетом имени файла, номеров строк и разделителей
+// групп контекста. Между группами из разных файлов разделитель выводится всегда.
+func (fs *fileSearch) printLine(lineNum int, line string, sep byte) {
+	if fs.config.HasContext() {
+		if fs.lastPrinted > 0 && lineNum > fs.lastPrinted+1 || fs.lastPrinted == 0 && fs.separate {
+			fs.out.WriteString("--\n")
 		}
-		line = prefix + line
 	}
 
-	return append(result, line)
+	if fs.config.WithFilename {
+		fs.out.WriteString(fs.name)
+		fs.out.WriteByte(sep)
+	}
+	if fs.config.LineNum {
+		fs.out.WriteString(strconv.Itoa(lineNum))
+		fs.out.WriteByte(sep)
+	}
+	fs.out.WriteString(line)
+	fs.out.WriteByte('\n')
+	fs.lastPrinted = lineNum
+	fs.printed = true
 }
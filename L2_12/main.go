@@ -9,30 +9,36 @@ func main() {
 	config, files, err := ParseFlags()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(2)
 	}
 
-	lines, err := ReadInput(files)
+	grep, err := NewGrep(config, os.Stdout)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
 	}
 
-	if len(lines) == 0 {
-		return
-	}
+	// Ошибка в одном файле не прерывает поиск в остальных
+	failed := false
+	grep.SearchFiles(files, func(err error) {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		failed = true
+	})
 
-	output, matchCount, err := Grep(lines, config)
-	if err != nil {
+	if err := grep.Flush(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(2)
 	}
 
-	for _, line := range output {
-		fmt.Println(line)
+	// Как и GNU grep, -q завершается успешно при найденном совпадении даже после ошибки
+	if config.Quiet && grep.MatchCount() > 0 {
+		return
+	}
+	if failed {
+		os.Exit(2)
 	}
 
-	if matchCount == 0 && !config.Count {
+	if grep.MatchCount() == 0 && !config.Count {
 		os.Exit(1)
 	}
 }
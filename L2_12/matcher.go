@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wordChar - класс символов, из которых состоят слова для -w
+const wordChar = `\p{L}\p{Nd}_`
+
+// newMatcher возвращает функцию проверки строки на совпадение хотя бы с одним
+// из шаблонов
+func newMatcher(config *Config) (func(line string) bool, error) {
+	if len(config.Patterns) == 0 {
+		return func(string) bool { return false }, nil
+	}
+	// Шаблоны без метасимволов ищутся как фиксированные строки - это намного
+	// быстрее, чем регулярное выражение из тысяч альтернатив
+	if config.Fixed || allLiteral(config.Patterns) {
+		return newFixedMatcher(config), nil
+	}
+	return newRegexpMatcher(config)
+}
+
+// allLiteral сообщает, что ни один шаблон не содержит метасимволов регулярных выражений
+func allLiteral(patterns []string) bool {
+	for _, pattern := range patterns {
+		if regexp.QuoteMeta(pattern) != pattern {
+			return false
+		}
+	}
+	return true
+}
+
+// newRegexpMatcher объединяет все шаблоны в одно регулярное выражение
+func newRegexpMatcher(config *Config) (func(line string) bool, error) {
+	alternatives := make([]string, len(config.Patterns))
+	for i, pattern := range config.Patterns {
+		alternatives[i] = "(?:" + pattern + ")"
+	}
+	pattern := strings.Join(alternatives, "|")
+
+	switch {
+	case config.LineRegexp:
+		pattern = "^(?:" + pattern + ")$"
+	case config.WordRegexp:
+		pattern = "(?:^|[^" + wordChar + "])(?:" + pattern + ")(?:[^" + wordChar + "]|$)"
+	}
+	if config.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %v", err)
+	}
+	return re.MatchString, nil
+}
+
+// newFixedMatcher ищет фиксированные строки: для -x - по множеству строк,
+// иначе - автоматом Ахо-Корасик за один проход по строке
+func newFixedMatcher(config *Config) func(line string) bool {
+	patterns := config.Patterns
+	fold := func(line string) string { return line }
+	if config.IgnoreCase {
+		fold = strings.ToLower
+		patterns = make([]string, len(config.Patterns))
+		for i, pattern := range config.Patterns {
+			patterns[i] = fold(pattern)
+		}
+	}
+
+	if config.LineRegexp {
+		set := make(map[string]struct{}, len(patterns))
+		for _, pattern := range patterns {
+			set[pattern] = struct{}{}
+		}
+		return func(line string) bool {
+			_, ok := set[fold(line)]
+			return ok
+		}
+	}
+
+	ac := newAhoCorasick(patterns)
+	if config.WordRegexp {
+		return func(line string) bool {
+			line = fold(line)
+			return ac.Find(line, func(start, end int) bool {
+				return isWordBoundary(line, start, end)
+			})
+		}
+	}
+	return func(line string) bool {
+		return ac.Find(fold(line), func(start, end int) bool { return true })
+	}
+}
+
+// isWordBoundary сообщает, что вхождение line[start:end] не окружено символами слова
+func isWordBoundary(line string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(line[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(line[end:]); end < len(line) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// isWordRune сообщает, что символ может входить в слово
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -17,11 +18,26 @@ type Config struct {
 	Invert     bool
 	Fixed      bool
 	LineNum    bool
-	Pattern    string
+	MaxCount   int
+	Quiet      bool
+	Patterns   []string
+	WordRegexp bool
+	LineRegexp bool
+
+	WithFilename      bool
+	NoFilename        bool
+	FilesWithMatches  bool
+	FilesWithoutMatch bool
+
+	Recursive   bool
+	FollowLinks bool
+	Include     []string
+	Exclude     []string
+	ExcludeDir  []string
 }
 
 func ParseFlags() (*Config, []string, error) {
-	config := &Config{}
+	config := &Config{MaxCount: -1}
 
 	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
 	fs.Usage = Usage
@@ -34,9 +50,21 @@ func ParseFlags() (*Config, []string, error) {
 	fs.BoolVar(&config.Invert, "v", false, "invert match")
 	fs.BoolVar(&config.Fixed, "F", false, "fixed string")
 	fs.BoolVar(&config.LineNum, "n", false, "print line numbers")
+	fs.IntVar(&config.MaxCount, "m", -1, "stop after N matching lines")
+	fs.BoolVar(&config.Quiet, "q", false, "quiet mode")
+	fs.BoolVar(&config.WithFilename, "H", false, "print file name for each match")
+	fs.BoolVar(&config.NoFilename, "h", false, "suppress file names")
+	fs.BoolVar(&config.FilesWithMatches, "l", false, "print only names of files with matches")
+	fs.BoolVar(&config.FilesWithoutMatch, "L", false, "print only names of files without matches")
+	fs.BoolVar(&config.Recursive, "r", false, "search directories recursively")
+	fs.BoolVar(&config.FollowLinks, "R", false, "search directories recursively, following symlinks")
+	fs.BoolVar(&config.WordRegexp, "w", false, "match whole words")
+	fs.BoolVar(&config.LineRegexp, "x", false, "match whole lines")
 
 	args := os.Args[1:]
 	var nonFlagArgs []string
+	// Если шаблоны заданы через -e или -f, все позиционные аргументы - файлы
+	var patternsGiven bool
 	var skipNext bool
 
 	for i := 0; i < len(args); i++ {
@@ -47,12 +75,50 @@ func ParseFlags() (*Config, []string, error) {
 
 		arg := args[i]
 
-		if strings.HasPrefix(arg, "-") && len(arg) > 1 && !strings.HasPrefix(arg, "--") {
-			if len(arg) > 2 {
+		if arg == "--" {
+			nonFlagArgs = append(nonFlagArgs, args[i+1:]...)
+			break
+		}
+
+		if strings.HasPrefix(arg, "--") {
+			name, val, hasVal := strings.Cut(arg[2:], "=")
+			if !hasVal {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("option --%s requires an argument", name)
+				}
+				val = args[i+1]
+				skipNext = true
+			}
+			switch name {
+			case "include":
+				config.Include = append(config.Include, val)
+			case "exclude":
+				config.Exclude = append(config.Exclude, val)
+			case "exclude-dir":
+				config.ExcludeDir = append(config.ExcludeDir, val)
+			case "regexp":
+				config.Patterns = append(config.Patterns, splitPatterns(val)...)
+				patternsGiven = true
+			case "file":
+				if err := setOption(config, "-f", val); err != nil {
+					return nil, nil, err
+				}
+				patternsGiven = true
+			default:
+				return nil, nil, fmt.Errorf("unknown option: --%s", name)
+			}
+		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			if len(arg) > 2 && strings.ContainsRune("ABCmef", rune(arg[1])) {
+				// Аргумент может идти слитно с опцией: -A2, -m10, -epattern
+				if err := setOption(config, arg[:2], arg[2:]); err != nil {
+					return nil, nil, err
+				}
+				patternsGiven = patternsGiven || arg[1] == 'e' || arg[1] == 'f'
+			} else if len(arg) > 2 {
 				flags := arg[1:]
 				for _, f := range flags {
 					switch f {
-					case 'A', 'B', 'C':
+					case 'A', 'B', 'C', 'm', 'e', 'f':
 						return nil, nil, fmt.Errorf("option -%c cannot be used in combined flags", f)
 					case 'c':
 						config.Count = true
@@ -64,32 +130,39 @@ func ParseFlags() (*Config, []string, error) {
 						config.Fixed = true
 					case 'n':
 						config.LineNum = true
+					case 'q':
+						config.Quiet = true
+					case 'H':
+						config.WithFilename = true
+					case 'h':
+						config.NoFilename = true
+					case 'l':
+						config.FilesWithMatches = true
+					case 'L':
+						config.FilesWithoutMatch = true
+					case 'r':
+						config.Recursive = true
+					case 'R':
+						config.FollowLinks = true
+					case 'w':
+						config.WordRegexp = true
+					case 'x':
+						config.LineRegexp = true
 					default:
 						return nil, nil, fmt.Errorf("unknown option: -%c", f)
 					}
 				}
 			} else {
 				switch arg {
-				case "-A", "-B", "-C":
-					if i+1 < len(args) {
-						val := args[i+1]
-						n, err := fmt.Sscanf(val, "%d", &config.Context)
-						if err != nil || n != 1 {
-							return nil, nil, fmt.Errorf("invalid number for %s: %s", arg, val)
-						}
-						switch arg {
-						case "-A":
-							config.After = config.Context
-						case "-B":
-							config.Before = config.Context
-						case "-C":
-							config.After = config.Context
-							config.Before = config.Context
-						}
-						skipNext = true
-					} else {
+				case "-A", "-B", "-C", "-m", "-e", "-f":
+					if i+1 >= len(args) {
 						return nil, nil, fmt.Errorf("option %s requires an argument", arg)
 					}
+					if err := setOption(config, arg, args[i+1]); err != nil {
+						return nil, nil, err
+					}
+					patternsGiven = patternsGiven || arg == "-e" || arg == "-f"
+					skipNext = true
 				default:
 					if err := fs.Parse([]string{arg}); err != nil {
 						return nil, nil, err
@@ -101,12 +174,32 @@ func ParseFlags() (*Config, []string, error) {
 		}
 	}
 
-	if len(nonFlagArgs) == 0 {
-		return nil, nil, fmt.Errorf("pattern is required")
+	files := nonFlagArgs
+	if !patternsGiven {
+		if len(nonFlagArgs) == 0 {
+			return nil, nil, fmt.Errorf("pattern is required")
+		}
+		config.Patterns = splitPatterns(nonFlagArgs[0])
+		files = nonFlagArgs[1:]
 	}
 
-	config.Pattern = nonFlagArgs[0]
-	files := nonFlagArgs[1:]
+	// -R - это -r с переходом по символическим ссылкам
+	if config.FollowLinks {
+		config.Recursive = true
+	}
+	// Без файлов читается stdin, а при -r - текущий каталог
+	if len(files) == 0 && !config.Recursive {
+		files = []string{"-"}
+	}
+
+	// Имена файлов выводятся по умолчанию, когда файлов несколько или обходится
+	// каталог; -h важнее
+	if len(files) > 1 || config.Recursive && (len(files) == 0 || isDir(files[0])) {
+		config.WithFilename = true
+	}
+	if config.NoFilename {
+		config.WithFilename = false
+	}
 
 	if config.After < 0 || config.Before < 0 || config.Context < 0 {
 		return nil, nil, fmt.Errorf("context lines count cannot be negative")
@@ -120,8 +213,80 @@ func ParseFlags() (*Config, []string, error) {
 	return config, files, nil
 }
 
+// HasContext сообщает, что выводится контекст и группы строк разделяются "--"
+func (c *Config) HasContext() bool {
+	return c.After > 0 || c.Before > 0
+}
+
+// PrintsLines сообщает, что выводятся сами строки, а не имена файлов или количество
+func (c *Config) PrintsLines() bool {
+	return !c.Count && !c.Quiet && !c.FilesWithMatches && !c.FilesWithoutMatch
+}
+
+// setOption разбирает аргумент опций -A, -B, -C, -m, -e и -f
+func setOption(config *Config, opt, val string) error {
+	switch opt {
+	case "-e":
+		config.Patterns = append(config.Patterns, splitPatterns(val)...)
+		return nil
+	case "-f":
+		patterns, err := readPatterns(val)
+		if err != nil {
+			return err
+		}
+		config.Patterns = append(config.Patterns, patterns...)
+		return nil
+	}
+
+	var n int
+	if _, err := fmt.Sscanf(val, "%d", &n); err != nil {
+		return fmt.Errorf("invalid number for %s: %s", opt, val)
+	}
+
+	switch opt {
+	case "-A":
+		config.After = n
+	case "-B":
+		config.Before = n
+	case "-C":
+		config.After = n
+		config.Before = n
+	case "-m":
+		config.MaxCount = n
+		return nil
+	}
+	config.Context = n
+	return nil
+}
+
+// splitPatterns разбивает шаблон с переводами строк на несколько шаблонов, как GNU grep
+func splitPatterns(pattern string) []string {
+	return strings.Split(pattern, "\n")
+}
+
+// readPatterns читает шаблоны из файла по одному на строку; "-" означает stdin.
+// Пустой файл не содержит ни одного шаблона и ничему не соответствует.
+func readPatterns(filename string) ([]string, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return splitPatterns(strings.TrimSuffix(string(data), "\n")), nil
+}
+
 func Usage() {
 	fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] PATTERN [FILE...]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s [OPTIONS] -e PATTERN... [-f FILE...] [FILE...]\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "Search for PATTERN in each FILE or standard input.\n\n")
 	fmt.Fprintf(os.Stderr, "Options:\n")
 	fmt.Fprintf(os.Stderr, "  -A N    print N lines after match\n")
@@ -131,5 +296,22 @@ func Usage() {
 	fmt.Fprintf(os.Stderr, "  -i      ignore case\n")
 	fmt.Fprintf(os.Stderr, "  -v      invert match\n")
 	fmt.Fprintf(os.Stderr, "  -F      fixed string (not regexp)\n")
+	fmt.Fprintf(os.Stderr, "  -e PATTERN  use PATTERN for matching (may be repeated)\n")
+	fmt.Fprintf(os.Stderr, "  -f FILE     read patterns from FILE, one per line\n")
+	fmt.Fprintf(os.Stderr, "  -w      match only whole words\n")
+	fmt.Fprintf(os.Stderr, "  -x      match only whole lines\n")
 	fmt.Fprintf(os.Stderr, "  -n      print line numbers\n")
+	fmt.Fprintf(os.Stderr, "  -m N    stop after N matching lines\n")
+	fmt.Fprintf(os.Stderr, "  -q      quiet: print nothing, exit 0 on first match\n")
+	fmt.Fprintf(os.Stderr, "  -H      print file name for each match\n")
+	fmt.Fprintf(os.Stderr, "  -h      suppress file names\n")
+	fmt.Fprintf(os.Stderr, "  -l      print only names of files with matches\n")
+	fmt.Fprintf(os.Stderr, "  -L      print only names of files without matches\n")
+	fmt.Fprintf(os.Stderr, "  -r      search directories recursively, skipping symlinks\n")
+	fmt.Fprintf(os.Stderr, "  -R      search directories recursively, following symlinks\n")
+	fmt.Fprintf(os.Stderr, "  --include=GLOB      search only files matching GLOB\n")
+	fmt.Fprintf(os.Stderr, "  --exclude=GLOB      skip files matching GLOB\n")
+	fmt.Fprintf(os.Stderr, "  --exclude-dir=GLOB  skip directories matching GLOB\n")
+	fmt.Fprintf(os.Stderr, "\nBinary files found in directories are skipped; for other binary files\n")
+	fmt.Fprintf(os.Stderr, "only a match is reported.\n")
 }
@@ -2,36 +2,56 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"io"
 	"os"
+	"strings"
 )
 
-func ReadInput(files []string) ([]string, error) {
-	var lines []string
+// readBufferSize - размер буфера чтения строк
+const readBufferSize = 64 * 1024
 
-	if len(files) == 0 {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-		}
-		return lines, scanner.Err()
+// StdinName - имя стандартного ввода в выводе, как у GNU grep
+const StdinName = "(standard input)"
+
+// OpenInput открывает файл для чтения. Имя "-" означает стандартный ввод.
+// Возвращает также имя, под которым источник выводится в результатах.
+func OpenInput(filename string) (io.ReadCloser, string, error) {
+	if filename == "-" {
+		return io.NopCloser(os.Stdin), StdinName, nil
 	}
 
-	for _, filename := range files {
-		file, err := os.Open(filename)
-		if err != nil {
-			return nil, err
-		}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, filename, err
+	}
+	return file, filename, nil
+}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
+// ForEachLine читает строки из r без ограничения на их длину и передает их в fn
+// без завершающего перевода строки. Возвращает false, если чтение остановил fn.
+func ForEachLine(r io.Reader, fn func(line string) bool) (bool, error) {
+	reader := bufio.NewReaderSize(r, readBufferSize)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if !fn(strings.TrimSuffix(line, "\n")) {
+				return false, nil
+			}
 		}
-		file.Close()
-
-		if err = scanner.Err(); err != nil {
-			return nil, err
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
 		}
 	}
+}
 
-	return lines, nil
+// IsBinary сообщает, что в первом прочитанном блоке есть нулевой байт, как у
+// двоичных файлов. Ждет только одно чтение, чтобы не задерживать вывод из канала.
+func IsBinary(reader *bufio.Reader) bool {
+	reader.Peek(1)
+	head, _ := reader.Peek(reader.Buffered())
+	return bytes.IndexByte(head, 0) >= 0
 }
@@ -216,9 +216,400 @@ else
     echo "✗ Результаты различаются"
 fi
 
+echo -e "\n=== Тест 13: Ограничение числа совпадений (-m 2) ==="
+echo "GNU grep -n -m 2 'apple':"
+grep -n -m 2 'apple' test_input.txt > grep_output13.txt
+cat grep_output13.txt
+
+echo -e "\nMy grep -n -m 2 'apple':"
+./my_grep -n -m 2 'apple' test_input.txt > my_grep_output13.txt
+cat my_grep_output13.txt
+
+echo -e "\nСравнение:"
+if diff grep_output13.txt my_grep_output13.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 14: Ограничение с контекстом (-m 1 -A 3) ==="
+echo "GNU grep -m 1 -A 3 'banana':"
+grep -m 1 -A 3 'banana' test_input.txt > grep_output14.txt
+cat grep_output14.txt
+
+echo -e "\nMy grep -m 1 -A 3 'banana':"
+./my_grep -m 1 -A 3 'banana' test_input.txt > my_grep_output14.txt
+cat my_grep_output14.txt
+
+echo -e "\nСравнение:"
+if diff grep_output14.txt my_grep_output14.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 15: Количество с ограничением (-c -m 2) ==="
+echo "GNU grep -c -m 2 'line':"
+grep -c -m 2 'line' test_input.txt > grep_output15.txt
+cat grep_output15.txt
+
+echo -e "\nMy grep -c -m 2 'line':"
+./my_grep -c -m 2 'line' test_input.txt > my_grep_output15.txt
+cat my_grep_output15.txt
+
+echo -e "\nСравнение:"
+if diff grep_output15.txt my_grep_output15.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 16: Тихий режим (-q) ==="
+grep -q 'apple' test_input.txt; grep_rc=$?
+./my_grep -q 'apple' test_input.txt > my_grep_output16.txt; my_rc=$?
+grep -q 'nomatch' test_input.txt; grep_rc="$grep_rc $?"
+./my_grep -q 'nomatch' test_input.txt >> my_grep_output16.txt; my_rc="$my_rc $?"
+echo "GNU grep -q: коды возврата $grep_rc"
+echo "My grep -q: коды возврата $my_rc"
+
+echo -e "\nСравнение:"
+if [ "$grep_rc" = "$my_rc" ] && [ ! -s my_grep_output16.txt ]; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+cat > test_input2.txt << EOF
+cherry pie
+plum line 2
+cherry jam
+EOF
+
+echo -e "\n=== Тест 17: Несколько файлов с номерами строк (-n) ==="
+echo "GNU grep -n 'cherry' test_input.txt test_input2.txt:"
+grep -n 'cherry' test_input.txt test_input2.txt > grep_output17.txt
+cat grep_output17.txt
+
+echo -e "\nMy grep -n 'cherry' test_input.txt test_input2.txt:"
+./my_grep -n 'cherry' test_input.txt test_input2.txt > my_grep_output17.txt
+cat my_grep_output17.txt
+
+echo -e "\nСравнение:"
+if diff grep_output17.txt my_grep_output17.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 18: Контекст в нескольких файлах (-A 1) ==="
+echo "GNU grep -A 1 'cherry' test_input.txt test_input2.txt:"
+grep -A 1 'cherry' test_input.txt test_input2.txt > grep_output18.txt
+cat grep_output18.txt
+
+echo -e "\nMy grep -A 1 'cherry' test_input.txt test_input2.txt:"
+./my_grep -A 1 'cherry' test_input.txt test_input2.txt > my_grep_output18.txt
+cat my_grep_output18.txt
+
+echo -e "\nСравнение:"
+if diff grep_output18.txt my_grep_output18.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 19: Количество по файлам (-c) ==="
+echo "GNU grep -c 'apple' test_input.txt test_input2.txt:"
+grep -c 'apple' test_input.txt test_input2.txt > grep_output19.txt
+cat grep_output19.txt
+
+echo -e "\nMy grep -c 'apple' test_input.txt test_input2.txt:"
+./my_grep -c 'apple' test_input.txt test_input2.txt > my_grep_output19.txt
+cat my_grep_output19.txt
+
+echo -e "\nСравнение:"
+if diff grep_output19.txt my_grep_output19.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 20: Без имен файлов (-h) ==="
+echo "GNU grep -h 'cherry' test_input.txt test_input2.txt:"
+grep -h 'cherry' test_input.txt test_input2.txt > grep_output20.txt
+cat grep_output20.txt
+
+echo -e "\nMy grep -h 'cherry' test_input.txt test_input2.txt:"
+./my_grep -h 'cherry' test_input.txt test_input2.txt > my_grep_output20.txt
+cat my_grep_output20.txt
+
+echo -e "\nСравнение:"
+if diff grep_output20.txt my_grep_output20.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 21: Имя файла для одного файла (-H) ==="
+echo "GNU grep -H 'grape' test_input.txt:"
+grep -H 'grape' test_input.txt > grep_output21.txt
+cat grep_output21.txt
+
+echo -e "\nMy grep -H 'grape' test_input.txt:"
+./my_grep -H 'grape' test_input.txt > my_grep_output21.txt
+cat my_grep_output21.txt
+
+echo -e "\nСравнение:"
+if diff grep_output21.txt my_grep_output21.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 22: Файлы с совпадениями (-l) ==="
+echo "GNU grep -l 'apple' test_input.txt test_input2.txt:"
+grep -l 'apple' test_input.txt test_input2.txt > grep_output22.txt
+cat grep_output22.txt
+
+echo -e "\nMy grep -l 'apple' test_input.txt test_input2.txt:"
+./my_grep -l 'apple' test_input.txt test_input2.txt > my_grep_output22.txt
+cat my_grep_output22.txt
+
+echo -e "\nСравнение:"
+if diff grep_output22.txt my_grep_output22.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 23: Файлы без совпадений (-L) ==="
+echo "GNU grep -L 'apple' test_input.txt test_input2.txt:"
+grep -L 'apple' test_input.txt test_input2.txt > grep_output23.txt
+cat grep_output23.txt
+
+echo -e "\nMy grep -L 'apple' test_input.txt test_input2.txt:"
+./my_grep -L 'apple' test_input.txt test_input2.txt > my_grep_output23.txt
+cat my_grep_output23.txt
+
+echo -e "\nСравнение:"
+if diff grep_output23.txt my_grep_output23.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+# Порядок обхода каталогов у GNU grep не определен, поэтому выводы сортируются
+mkdir -p test_dir/sub
+cp test_input.txt test_dir/a.txt
+cp test_input2.txt test_dir/sub/b.go
+cp test_input2.txt test_dir/c.go
+
+echo -e "\n=== Тест 24: Рекурсивный поиск (-r) ==="
+echo "GNU grep -r -n 'cherry' test_dir:"
+grep -r -n 'cherry' test_dir | sort > grep_output24.txt
+cat grep_output24.txt
+
+echo -e "\nMy grep -r -n 'cherry' test_dir:"
+./my_grep -r -n 'cherry' test_dir | sort > my_grep_output24.txt
+cat my_grep_output24.txt
+
+echo -e "\nСравнение:"
+if diff grep_output24.txt my_grep_output24.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 25: Рекурсивный поиск с --include ==="
+echo "GNU grep -r --include='*.go' 'cherry' test_dir:"
+grep -r --include='*.go' 'cherry' test_dir | sort > grep_output25.txt
+cat grep_output25.txt
+
+echo -e "\nMy grep -r --include='*.go' 'cherry' test_dir:"
+./my_grep -r --include='*.go' 'cherry' test_dir | sort > my_grep_output25.txt
+cat my_grep_output25.txt
+
+echo -e "\nСравнение:"
+if diff grep_output25.txt my_grep_output25.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 26: Рекурсивный поиск с --exclude-dir ==="
+echo "GNU grep -r --exclude-dir=sub 'cherry' test_dir:"
+grep -r --exclude-dir=sub 'cherry' test_dir | sort > grep_output26.txt
+cat grep_output26.txt
+
+echo -e "\nMy grep -r --exclude-dir=sub 'cherry' test_dir:"
+./my_grep -r --exclude-dir=sub 'cherry' test_dir | sort > my_grep_output26.txt
+cat my_grep_output26.txt
+
+echo -e "\nСравнение:"
+if diff grep_output26.txt my_grep_output26.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+cat > test_patterns.txt << EOF
+apple
+line 1
+EOF
+
+echo -e "\n=== Тест 27: Несколько шаблонов (-e) ==="
+echo "GNU grep -n -e 'cherry' -e 'fig':"
+grep -n -e 'cherry' -e 'fig' test_input.txt > grep_output27.txt
+cat grep_output27.txt
+
+echo -e "\nMy grep -n -e 'cherry' -e 'fig':"
+./my_grep -n -e 'cherry' -e 'fig' test_input.txt > my_grep_output27.txt
+cat my_grep_output27.txt
+
+echo -e "\nСравнение:"
+if diff grep_output27.txt my_grep_output27.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 28: Шаблоны из файла (-f) ==="
+echo "GNU grep -f test_patterns.txt:"
+grep -f test_patterns.txt test_input.txt > grep_output28.txt
+cat grep_output28.txt
+
+echo -e "\nMy grep -f test_patterns.txt:"
+./my_grep -f test_patterns.txt test_input.txt > my_grep_output28.txt
+cat my_grep_output28.txt
+
+echo -e "\nСравнение:"
+if diff grep_output28.txt my_grep_output28.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 29: Фиксированные строки из файла (-F -f) ==="
+echo "GNU grep -F -i -f test_patterns.txt:"
+grep -F -i -f test_patterns.txt test_input.txt > grep_output29.txt
+cat grep_output29.txt
+
+echo -e "\nMy grep -F -i -f test_patterns.txt:"
+./my_grep -F -i -f test_patterns.txt test_input.txt > my_grep_output29.txt
+cat my_grep_output29.txt
+
+echo -e "\nСравнение:"
+if diff grep_output29.txt my_grep_output29.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 30: Целые слова (-w) ==="
+echo "GNU grep -w 'line':"
+grep -w 'line' test_input.txt > grep_output30.txt
+cat grep_output30.txt
+
+echo -e "\nMy grep -w 'line':"
+./my_grep -w 'line' test_input.txt > my_grep_output30.txt
+cat my_grep_output30.txt
+
+echo -e "\nСравнение:"
+if diff grep_output30.txt my_grep_output30.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 31: Целые слова для фиксированных строк (-F -w) ==="
+echo "GNU grep -F -w -e 'apple' -e 'line 1':"
+grep -F -w -e 'apple' -e 'line 1' test_input.txt > grep_output31.txt
+cat grep_output31.txt
+
+echo -e "\nMy grep -F -w -e 'apple' -e 'line 1':"
+./my_grep -F -w -e 'apple' -e 'line 1' test_input.txt > my_grep_output31.txt
+cat my_grep_output31.txt
+
+echo -e "\nСравнение:"
+if diff grep_output31.txt my_grep_output31.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 32: Целые строки (-x) ==="
+echo "GNU grep -x -e 'fig line 6' -e 'apple':"
+grep -x -e 'fig line 6' -e 'apple' test_input.txt > grep_output32.txt
+cat grep_output32.txt
+
+echo -e "\nMy grep -x -e 'fig line 6' -e 'apple':"
+./my_grep -x -e 'fig line 6' -e 'apple' test_input.txt > my_grep_output32.txt
+cat my_grep_output32.txt
+
+echo -e "\nСравнение:"
+if diff grep_output32.txt my_grep_output32.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 33: Двоичный файл в операндах ==="
+printf 'apple\0line\napple line 2\n' > test_binary.bin
+echo "GNU grep 'apple' test_binary.bin test_input.txt:"
+{ grep 'apple' test_binary.bin test_input.txt 2>&1; echo "exit $?"; } > grep_output33.txt
+cat grep_output33.txt
+
+echo -e "\nMy grep 'apple' test_binary.bin test_input.txt:"
+{ ./my_grep 'apple' test_binary.bin test_input.txt 2>&1; echo "exit $?"; } > my_grep_output33.txt
+cat my_grep_output33.txt
+
+echo -e "\nСравнение:"
+if diff grep_output33.txt my_grep_output33.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+rm -f test_binary.bin
+
+mkdir -p test_dir/sub/deep
+cp test_input2.txt test_dir/sub/deep/d.go
+cp test_input.txt test_dir/sub/deep/b.go
+
+echo -e "\n=== Тест 34: --include сверяется с базовым именем во вложенных каталогах ==="
+echo "GNU grep -r --include='sub/*.go' --include='d.go' 'cherry' test_dir:"
+grep -r --include='sub/*.go' --include='d.go' 'cherry' test_dir | sort > grep_output34.txt
+cat grep_output34.txt
+
+echo -e "\nMy grep -r --include='sub/*.go' --include='d.go' 'cherry' test_dir:"
+./my_grep -r --include='sub/*.go' --include='d.go' 'cherry' test_dir | sort > my_grep_output34.txt
+cat my_grep_output34.txt
+
+echo -e "\nСравнение:"
+if diff grep_output34.txt my_grep_output34.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
+echo -e "\n=== Тест 35: --exclude сверяется с базовым именем во вложенных каталогах ==="
+echo "GNU grep -r --exclude='b.go' --exclude='deep/*.go' 'cherry' test_dir:"
+grep -r --exclude='b.go' --exclude='deep/*.go' 'cherry' test_dir | sort > grep_output35.txt
+cat grep_output35.txt
+
+echo -e "\nMy grep -r --exclude='b.go' --exclude='deep/*.go' 'cherry' test_dir:"
+./my_grep -r --exclude='b.go' --exclude='deep/*.go' 'cherry' test_dir | sort > my_grep_output35.txt
+cat my_grep_output35.txt
+
+echo -e "\nСравнение:"
+if diff grep_output35.txt my_grep_output35.txt; then
+    echo "✓ Результаты идентичны"
+else
+    echo "✗ Результаты различаются"
+fi
+
 echo -e "\n=== Сводка ==="
 echo "Созданные файлы:"
 ls -la *output*.txt test_input.txt
+rm -rf test_dir
 
 echo -e "\nДля детального сравнения используйте:"
 echo "  diff -u grep_outputX.txt my_grep_outputX.txt"
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// maxBufferedOutput - сколько байт вывода файла, ожидающего своей очереди,
+// хранится в памяти. Остальное пишется во временный файл.
+const maxBufferedOutput = 256 << 10
+
+// fileJob - файл, поставленный в очередь пула поиска
+type fileJob struct {
+	path   string
+	nested bool // файл найден при обходе каталога
+	output fileOutput
+	result chan fileResult
+}
+
+// fileResult - итог поиска в одном файле
+type fileResult struct {
+	name       string
+	matchCount int
+	printed    bool
+	binary     bool // совпадение найдено в двоичном файле
+	err        error
+}
+
+// fileOutput - вывод поиска в одном файле. Пока файл не дошел до начала очереди,
+// вывод копится в памяти, а сверх maxBufferedOutput - во временном файле.
+// Файл в начале очереди пишет прямо в выходной поток.
+type fileOutput struct {
+	mu       sync.Mutex
+	out      *bufio.Writer // выходной поток, когда файл в начале очереди
+	separate bool          // перед первой строкой нужен разделитель "--"
+	buf      bytes.Buffer
+	spill    *os.File
+}
+
+// SearchFiles ищет во всех входных файлах и каталогах. Несколько файлов
+// обрабатываются параллельно пулом из runtime.NumCPU() горутин, а результаты
+// выводятся в порядке файлов: первый в очереди файл пишет вывод сразу, остальные
+// копят его до своей очереди. Единственный файл без -r читается без пула.
+// Ошибки отдельных файлов передаются в report и не прерывают поиск.
+func (g *Grep) SearchFiles(files []string, report func(err error)) {
+	if !g.config.Recursive && len(files) == 1 {
+		r, name, err := OpenInput(files[0])
+		if err == nil {
+			err = g.Search(name, r)
+			r.Close()
+		}
+		if err != nil {
+			report(err)
+		}
+		return
+	}
+
+	workers := runtime.NumCPU()
+	jobs := make(chan *fileJob)
+	// Ограничивает число файлов, результаты которых ждут вывода
+	ordered := make(chan *fileJob, workers*4)
+
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+
+		WalkInputs(files, g.config, func(path string, nested bool, err error) bool {
+			if g.Done() {
+				return false
+			}
+
+			job := &fileJob{path: path, nested: nested, result: make(chan fileResult, 1)}
+			ordered <- job
+			if err != nil {
+				job.result <- fileResult{err: err}
+				return true
+			}
+			jobs <- job
+			return true
+		})
+	}()
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				job.result <- g.searchQueued(job)
+			}
+		}()
+	}
+
+	for job := range ordered {
+		separate := g.printed && g.config.HasContext() && g.config.PrintsLines()
+		if err := job.output.promote(g.out, separate); err != nil {
+			report(err)
+		}
+		res := <-job.result
+		if res.err != nil {
+			report(res.err)
+		}
+		g.add(res.name, res.matchCount, res.printed, res.binary)
+	}
+}
+
+// searchQueued ищет в файле из очереди, записывая вывод в job.output
+func (g *Grep) searchQueued(job *fileJob) fileResult {
+	var res fileResult
+	if g.Done() {
+		return res
+	}
+
+	r, name, err := OpenInput(job.path)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer r.Close()
+
+	out := bufio.NewWriter(&job.output)
+	fs := g.newFileSearch(name, out, false)
+	fs.skipBinary = job.nested
+	res.err = fs.run(r)
+	if err := out.Flush(); err != nil && res.err == nil {
+		res.err = err
+	}
+	res.name = name
+	res.matchCount = fs.matchCount
+	res.printed = fs.printed
+	res.binary = fs.binaryMatch()
+	return res
+}
+
+// Write пишет вывод в выходной поток, если файл в начале очереди, иначе откладывает его
+func (o *fileOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch {
+	case o.out != nil:
+		if o.separate && len(p) > 0 {
+			o.out.WriteString("--\n")
+			o.separate = false
+		}
+		return o.out.Write(p)
+	case o.spill != nil:
+		return o.spill.Write(p)
+	case o.buf.Len()+len(p) <= maxBufferedOutput:
+		return o.buf.Write(p)
+	}
+
+	spill, err := os.CreateTemp("", "my_grep-*")
+	if err != nil {
+		return 0, err
+	}
+	o.spill = spill
+	if _, err := spill.Write(o.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	o.buf = bytes.Buffer{}
+	return spill.Write(p)
+}
+
+// promote вызывается, когда файл дошел до начала очереди: отложенный вывод
+// переносится в out, а дальнейшие записи идут в out напрямую. При separate
+// перед первой строкой файла выводится разделитель групп контекста.
+func (o *fileOutput) promote(out *bufio.Writer, separate bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if separate && (o.buf.Len() > 0 || o.spill != nil) {
+		out.WriteString("--\n")
+		separate = false
+	}
+	out.Write(o.buf.Bytes())
+	o.buf = bytes.Buffer{}
+	o.out, o.separate = out, separate
+
+	if o.spill == nil {
+		return nil
+	}
+	defer os.Remove(o.spill.Name())
+	defer o.spill.Close()
+
+	_, err := o.spill.Seek(0, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(out, o.spill)
+	}
+	o.spill = nil
+	return err
+}
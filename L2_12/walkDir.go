@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walker обходит операнды командной строки и каталоги при -r/-R
+type walker struct {
+	config    *Config
+	visit     func(path string, nested bool, err error) bool
+	ancestors []os.FileInfo // каталоги текущего пути, для защиты от циклов при -R
+}
+
+// WalkInputs перечисляет файлы для поиска в детерминированном порядке: операнды -
+// в порядке командной строки, содержимое каталогов - по алфавиту. При -r без
+// операндов обходится текущий каталог. Флаг nested у visit отмечает файлы, найденные
+// внутри каталогов, а не указанные явно. Обход прекращается, когда visit вернет false.
+func WalkInputs(files []string, config *Config, visit func(path string, nested bool, err error) bool) {
+	w := &walker{config: config, visit: visit}
+
+	if len(files) == 0 {
+		info, err := os.Stat(".")
+		if err != nil {
+			visit(".", false, err)
+			return
+		}
+		w.walkDir("", info)
+		return
+	}
+
+	for _, path := range files {
+		if !w.operand(path) {
+			return
+		}
+	}
+}
+
+// operand обрабатывает файл или каталог из командной строки. Символические
+// ссылки в операндах разыменовываются и при -r.
+func (w *walker) operand(path string) bool {
+	if path == "-" {
+		return w.visit(path, false, nil)
+	}
+
+	if w.config.Recursive {
+		info, err := os.Stat(path)
+		if err != nil {
+			return w.visit(path, false, err)
+		}
+		if info.IsDir() {
+			if matchSuffix(w.config.ExcludeDir, strings.TrimRight(path, "/")) {
+				return true
+			}
+			return w.walkDir(path, info)
+		}
+	}
+
+	if !w.included(path, matchSuffix) {
+		return true
+	}
+	return w.visit(path, false, nil)
+}
+
+// walkDir рекурсивно обходит каталог. Символические ссылки внутри него
+// пропускаются при -r и разыменовываются при -R.
+func (w *walker) walkDir(dir string, info os.FileInfo) bool {
+	for _, ancestor := range w.ancestors {
+		if os.SameFile(ancestor, info) {
+			return true
+		}
+	}
+	w.ancestors = append(w.ancestors, info)
+	defer func() { w.ancestors = w.ancestors[:len(w.ancestors)-1] }()
+
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return w.visit(readDir, true, err)
+	}
+
+	for _, entry := range entries {
+		path := joinPath(dir, entry.Name())
+
+		var entryInfo os.FileInfo
+		if entry.Type()&os.ModeSymlink != 0 {
+			if !w.config.FollowLinks {
+				continue
+			}
+			entryInfo, err = os.Stat(path)
+		} else {
+			entryInfo, err = entry.Info()
+		}
+		if err != nil {
+			if !w.visit(path, true, err) {
+				return false
+			}
+			continue
+		}
+
+		switch {
+		case entryInfo.IsDir():
+			if matchBase(w.config.ExcludeDir, path) {
+				continue
+			}
+			if !w.walkDir(path, entryInfo) {
+				return false
+			}
+		case entryInfo.Mode().IsRegular():
+			if w.included(path, matchBase) && !w.visit(path, true, nil) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// included проверяет файл по шаблонам --include и --exclude. Как в GNU grep, файлы
+// из каталогов сверяются с шаблонами по базовому имени (match = matchBase), а операнды
+// командной строки - по любому хвосту пути (match = matchSuffix).
+func (w *walker) included(path string, match func(patterns []string, path string) bool) bool {
+	if len(w.config.Include) > 0 && !match(w.config.Include, path) {
+		return false
+	}
+	return !match(w.config.Exclude, path)
+}
+
+// matchBase сообщает, подходит ли под один из шаблонов базовое имя пути
+func matchBase(patterns []string, path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSuffix сообщает, подходит ли под один из шаблонов путь целиком или любой
+// его хвост после "/", в том числе базовое имя
+func matchSuffix(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		for name := path; ; {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+			i := strings.IndexByte(name, '/')
+			if i < 0 {
+				break
+			}
+			name = name[i+1:]
+		}
+	}
+	return false
+}
+
+// joinPath соединяет каталог и имя, сохраняя вид пути из командной строки
+// (в отличие от filepath.Join, не убирает "./")
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	if strings.HasSuffix(dir, "/") {
+		return dir + name
+	}
+	return dir + "/" + name
+}
+
+// isDir сообщает, что путь указывает на каталог
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}